@@ -0,0 +1,92 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// MultiADAEAD is implemented by the AEADs returned from NewRFC and NewDraft.
+// It allows additional data held in several non-contiguous slices to be
+// authenticated without first concatenating them.
+type MultiADAEAD interface {
+	cipher.AEAD
+
+	// SealWithADs behaves like Seal with the concatenation of ads as the
+	// additional data. The output is identical to that of Seal.
+	SealWithADs(dst, nonce, plaintext []byte, ads ...[]byte) []byte
+
+	// OpenWithADs behaves like Open with the concatenation of ads as the
+	// additional data.
+	OpenWithADs(dst, nonce, ciphertext []byte, ads ...[]byte) ([]byte, error)
+}
+
+// adsLen returns the total length of ads.
+func adsLen(ads [][]byte) (n uint64) {
+	for _, ad := range ads {
+		n += uint64(len(ad))
+	}
+
+	return n
+}
+
+// writeADs writes ads to the MAC as the additional data.
+func (w *macWriter) writeADs(ads [][]byte) {
+	for _, ad := range ads {
+		w.writeData(ad)
+	}
+
+	w.endData()
+}
+
+func (k *chacha20Key) SealWithADs(dst, nonce, plaintext []byte, ads ...[]byte) []byte {
+	if err := k.checkSeal(nonce, len(plaintext), adsLen(ads)); err != nil {
+		panic(err)
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305.TagSize)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	ciphertext := out[:len(plaintext)]
+	c.XORKeyStream(ciphertext, plaintext)
+
+	mac := k.newMACWriter(pk[:32])
+	mac.writeADs(ads)
+	mac.Write(ciphertext)
+	mac.sum(out[len(plaintext):])
+
+	return ret
+}
+
+func (k *chacha20Key) OpenWithADs(dst, nonce, ciphertext []byte, ads ...[]byte) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, adsLen(ads)); err != nil {
+		return nil, err
+	}
+
+	c := k.stream(nonce)
+
+	pk := k.keyBlock(c)
+	defer k.putKeyBlock(pk)
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	var expectedTag [poly1305.TagSize]byte
+	mac := k.newMACWriter(pk[:32])
+	mac.writeADs(ads)
+	mac.Write(ciphertext)
+	mac.sum(expectedTag[:])
+
+	return k.decryptVerified(c, dst, ciphertext, k.tagEqual(expectedTag[:], tag))
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSealWithADs(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), vectors []testVector) {
+	for _, vector := range vectors {
+		c, err := newChaCha20Poly1305(vector.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := c.(MultiADAEAD)
+		third := len(vector.data) / 3
+
+		for _, ads := range [][][]byte{
+			{vector.data},
+			{vector.data[:len(vector.data)/2], vector.data[len(vector.data)/2:]},
+			{nil, vector.data[:third], nil, vector.data[third : 2*third], vector.data[2*third:]},
+		} {
+			actual := m.SealWithADs(nil, vector.nonce, vector.plaintext, ads...)
+			if !bytes.Equal(vector.ciphertext, actual) {
+				t.Errorf("Bad seal with %d ADs: expected %x, was %x", len(ads), vector.ciphertext, actual)
+			}
+
+			plaintext, err := m.OpenWithADs(nil, vector.nonce, vector.ciphertext, ads...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(vector.plaintext, plaintext) {
+				t.Errorf("Bad open with %d ADs: expected %x, was %x", len(ads), vector.plaintext, plaintext)
+			}
+		}
+
+		if _, err := m.OpenWithADs(nil, vector.nonce, vector.ciphertext, vector.data, []byte{0}); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error but was %v", err)
+		}
+
+		if len(vector.data) == 0 {
+			continue
+		}
+
+		// Moving the boundary between slices mustn't matter, but
+		// reordering them must.
+		swapped := [][]byte{vector.data[1:], vector.data[:1]}
+		if !bytes.Equal(vector.data, bytes.Join(swapped, nil)) {
+			if _, err := m.OpenWithADs(nil, vector.nonce, vector.ciphertext, swapped...); err != ErrAuthFailed {
+				t.Errorf("Expected message authentication failed error but was %v", err)
+			}
+		}
+	}
+}
+
+func TestRFCSealWithADs(t *testing.T) {
+	testSealWithADs(t, NewRFC, rfcTestVectors)
+}
+
+func TestDraftSealWithADs(t *testing.T) {
+	testSealWithADs(t, NewDraft, draftTestVectors)
+}
+
+func TestOpenWithADsCompare(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	var calls int
+	c, err := NewRFCWithCompare(key, func(x, y []byte) int {
+		calls++
+		return 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := c.(MultiADAEAD)
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	ciphertext := m.SealWithADs(nil, nonce, plaintext, []byte("whoah"), []byte(" yeah"))
+
+	dst := bytes.Repeat([]byte{0xff}, len(plaintext))
+	if _, err = m.OpenWithADs(dst[:0], nonce, ciphertext, []byte("whoah"), []byte(" yeah")); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected compare to be invoked once but was invoked %d times", calls)
+	}
+
+	if !bytes.Equal(dst, make([]byte, len(plaintext))) {
+		t.Errorf("Expected dst to be zeroed but was %x", dst)
+	}
+}
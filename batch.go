@@ -0,0 +1,158 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrBatchLength is returned by SealBatch when its arguments have different
+// lengths.
+var ErrBatchLength = errors.New("batch slices have different lengths")
+
+// BatchSealAEAD is implemented by the AEADs returned from NewRFC, NewDraft
+// and NewX. It allows many messages to be sealed under one key with less
+// per-message overhead than calling Seal in a loop.
+type BatchSealAEAD interface {
+	cipher.AEAD
+
+	// SealBatch seals plaintexts[i] with nonces[i] and datas[i],
+	// appending the result to dsts[i], and returns the sealed messages.
+	// dsts and datas may be nil, in which case new buffers are used and
+	// there is no additional data, respectively; otherwise all the
+	// slices must be the same length or ErrBatchLength is returned.
+	//
+	// Every message is checked before any is sealed, so on error none of
+	// dsts is written to. The errors are those of SealSafe.
+	SealBatch(dsts, nonces, plaintexts, datas [][]byte) ([][]byte, error)
+}
+
+// checkBatch returns ErrBatchLength if dsts or datas is non-nil and not the
+// same length as nonces and plaintexts.
+func checkBatch(dsts, nonces, plaintexts, datas [][]byte) error {
+	n := len(nonces)
+	if len(plaintexts) != n ||
+		(dsts != nil && len(dsts) != n) ||
+		(datas != nil && len(datas) != n) {
+		return ErrBatchLength
+	}
+
+	return nil
+}
+
+// batchIndex returns s[i] or nil if s is nil.
+func batchIndex(s [][]byte, i int) []byte {
+	if s == nil {
+		return nil
+	}
+
+	return s[i]
+}
+
+// SealBatch takes a single buffer for the counter-0 block from keyBlockPool
+// and, where dsts[i] is nil, allocates the output for every such message at
+// once. ChaCha20 has no key schedule, so the cipher itself is still set up
+// for each message; see stream.
+func (k *chacha20Key) SealBatch(dsts, nonces, plaintexts, datas [][]byte) ([][]byte, error) {
+	if err := checkBatch(dsts, nonces, plaintexts, datas); err != nil {
+		return nil, err
+	}
+
+	if k.destroyed {
+		return nil, ErrInvalidKey
+	}
+
+	var slabLen int
+	for i, nonce := range nonces {
+		if err := k.checkSeal(nonce, len(plaintexts[i]), uint64(len(batchIndex(datas, i)))); err != nil {
+			return nil, err
+		}
+
+		if batchIndex(dsts, i) == nil {
+			slabLen += len(plaintexts[i]) + poly1305.TagSize
+		}
+	}
+
+	slab := make([]byte, slabLen)
+
+	var pk *[64]byte
+	defer func() {
+		if pk != nil {
+			k.putKeyBlock(pk)
+		}
+	}()
+
+	out := make([][]byte, len(nonces))
+	for i, nonce := range nonces {
+		plaintext, data := plaintexts[i], batchIndex(datas, i)
+
+		dst := batchIndex(dsts, i)
+		if dst == nil {
+			n := len(plaintext) + poly1305.TagSize
+			dst, slab = slab[:0:n], slab[n:]
+		}
+
+		checkDst(1, dst, plaintext, k.Overhead())
+
+		c := k.stream(nonce)
+
+		if len(plaintext) <= smallSealLen {
+			out[i] = k.sealSmall(c, dst, plaintext, data)
+			continue
+		}
+
+		if pk == nil {
+			pk = k.keyBlock(c)
+		} else {
+			*pk = [64]byte{}
+			c.XORKeyStream(pk[:], pk[:])
+		}
+
+		out[i] = k.seal(c, pk[:32], dst, plaintext, data, nil)
+	}
+
+	return out, nil
+}
+
+func (k *xchacha20Key) SealBatch(dsts, nonces, plaintexts, datas [][]byte) ([][]byte, error) {
+	if err := checkBatch(dsts, nonces, plaintexts, datas); err != nil {
+		return nil, err
+	}
+
+	if k.destroyed {
+		return nil, ErrInvalidKey
+	}
+
+	for _, nonce := range nonces {
+		if len(nonce) != XNonceSize {
+			return nil, ErrInvalidNonce
+		}
+	}
+
+	var rfc chacha20Key
+	for _, plaintext := range plaintexts {
+		if rfc.plaintextTooLong(uint64(len(plaintext))) {
+			return nil, ErrPlaintextTooLong
+		}
+	}
+
+	out := make([][]byte, len(nonces))
+	for i, nonce := range nonces {
+		sk, rfcNonce := k.subkey(nonce)
+
+		var err error
+		out[i], err = sk.sealSafe(1, batchIndex(dsts, i), rfcNonce, plaintexts[i], batchIndex(datas, i))
+		wipe(sk.key[:])
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
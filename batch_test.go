@@ -0,0 +1,132 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSealBatch(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bc := c.(BatchSealAEAD)
+
+	var nonces, plaintexts, datas [][]byte
+	for i, size := range []int{0, 1, 64, 65, 100, 1000} {
+		nonce := make([]byte, c.NonceSize())
+		nonce[0] = byte(i)
+
+		nonces = append(nonces, nonce)
+		plaintexts = append(plaintexts, bytes.Repeat([]byte{byte(i)}, size))
+		datas = append(datas, bytes.Repeat([]byte{0x42}, i))
+	}
+
+	dsts := make([][]byte, len(nonces))
+	dsts[2] = []byte("prefix")
+
+	sealed, err := bc.SealBatch(dsts, nonces, plaintexts, datas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range nonces {
+		expected := c.Seal(append([]byte(nil), dsts[i]...), nonces[i], plaintexts[i], datas[i])
+		if !bytes.Equal(expected, sealed[i]) {
+			t.Errorf("Bad seal of message %d: expected %x, was %x", i, expected, sealed[i])
+		}
+	}
+
+	sealed, err = bc.SealBatch(nil, nonces, plaintexts, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range nonces {
+		if expected := c.Seal(nil, nonces[i], plaintexts[i], nil); !bytes.Equal(expected, sealed[i]) {
+			t.Errorf("Bad seal of message %d: expected %x, was %x", i, expected, sealed[i])
+		}
+	}
+
+	if _, err = bc.SealBatch(nil, nonces, plaintexts[1:], nil); err != ErrBatchLength {
+		t.Errorf("Expected batch length error but was %v", err)
+	}
+
+	if _, err = bc.SealBatch(dsts[1:], nonces, plaintexts, datas); err != ErrBatchLength {
+		t.Errorf("Expected batch length error but was %v", err)
+	}
+
+	if _, err = bc.SealBatch(nil, nonces, plaintexts, datas[1:]); err != ErrBatchLength {
+		t.Errorf("Expected batch length error but was %v", err)
+	}
+
+	dst := make([]byte, 0, 1024)
+	dsts[0] = dst
+	nonces[len(nonces)-1] = nonces[len(nonces)-1][1:]
+
+	if _, err = bc.SealBatch(dsts, nonces, plaintexts, datas); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+
+	if dst = dst[:cap(dst)]; !bytes.Equal(dst, make([]byte, len(dst))) {
+		t.Error("SealBatch wrote to dst despite returning an error")
+	}
+}
+
+func TestRFCSealBatch(t *testing.T) {
+	testSealBatch(t, NewRFC)
+}
+
+func TestDraftSealBatch(t *testing.T) {
+	testSealBatch(t, NewDraft)
+}
+
+func TestXSealBatch(t *testing.T) {
+	testSealBatch(t, NewX)
+}
+
+func BenchmarkSealBatch(b *testing.B) {
+	const batch = 1000
+
+	key := make([]byte, KeySize)
+	c, _ := NewRFC(key)
+
+	nonces := make([][]byte, batch)
+	plaintexts := make([][]byte, batch)
+	for i := range nonces {
+		nonces[i] = make([]byte, c.NonceSize())
+		nonces[i][0], nonces[i][1] = byte(i), byte(i>>8)
+		plaintexts[i] = make([]byte, 100)
+	}
+
+	b.Run("Loop", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(batch * 100)
+
+		for i := 0; i < b.N; i++ {
+			for j := range nonces {
+				c.Seal(nil, nonces[j], plaintexts[j], nil)
+			}
+		}
+	})
+
+	b.Run("Batch", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(batch * 100)
+
+		for i := 0; i < b.N; i++ {
+			c.(BatchSealAEAD).SealBatch(nil, nonces, plaintexts, nil)
+		}
+	})
+}
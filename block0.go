@@ -0,0 +1,32 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+// ChaCha20Block0 returns the ChaCha20 counter-0 keystream block for the given
+// key and nonce. The first 32 bytes of this block are the one-time Poly1305
+// key used by Seal and Open. The nonce may be either 8 or 12 bytes long.
+//
+// This is intended solely for verifying hardware implementations and for
+// debugging. The returned block is keystream material and must never be
+// logged or otherwise exposed in production.
+func ChaCha20Block0(key, nonce []byte) ([64]byte, error) {
+	var block [64]byte
+
+	if len(key) != KeySize {
+		return block, ErrInvalidKey
+	}
+
+	if len(nonce) != DraftNonceSize && len(nonce) != RFCNonceSize {
+		return block, ErrInvalidNonce
+	}
+
+	c, err := newChaCha20(key, nonce)
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	c.XORKeyStream(block[:], block[:])
+	return block, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestChaCha20Block0(t *testing.T) {
+	// https://tools.ietf.org/html/rfc7539#appendix-A.1 test vector #1
+	block, err := ChaCha20Block0(make([]byte, KeySize), make([]byte, 12))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := mustHexDecode("76b8e0ada0f13d90405d6ae55386bd28bdd219b8a08ded1aa836efcc8b770dc7" +
+		"da41597c5157488d7724e03fb8d84a376a43b8f41518a11cc387b669b2ee6586")
+	if !bytes.Equal(expected, block[:]) {
+		t.Errorf("Bad block: expected %x, was %x", expected, block)
+	}
+
+	// https://tools.ietf.org/html/rfc7539#section-2.6.2
+	block, err = ChaCha20Block0(mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		mustHexDecode("000000000001020304050607"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = mustHexDecode("8ad5a08b905f81cc815040274ab29471a833b637e3fd0da508dbb8e2fdd1a646")
+	if !bytes.Equal(expected, block[:32]) {
+		t.Errorf("Bad Poly1305 key: expected %x, was %x", expected, block[:32])
+	}
+
+	if _, err = ChaCha20Block0(make([]byte, KeySize-1), make([]byte, 12)); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	if _, err = ChaCha20Block0(make([]byte, KeySize), make([]byte, 11)); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// Block0TailAEAD is implemented by the AEADs returned from NewRFC and
+// NewDraft. It exposes the last 32 bytes of the counter-0 keystream block,
+// which Seal and Open otherwise discard.
+//
+// The counter-0 block is ChaCha20 output that is never used to encrypt
+// data, as encryption starts at counter 1, and its first 32 bytes are used
+// only as the one-time Poly1305 key. Under the assumption that ChaCha20 is a
+// PRF, the remaining 32 bytes are independent of both and so may be used for
+// an additional per-message key or for a key commitment without weakening
+// the AEAD, and without generating a further keystream block. They must not
+// be used for anything that would also use the same bytes under a different
+// construction, and if revealed they disclose nothing about the key or the
+// rest of the keystream. NewRFCDualTag uses them as its second Poly1305 key;
+// NewRFCCommitting does not use them.
+//
+// Whether such a commitment is sufficient for a particular protocol is for
+// the caller to decide; this package makes no claims beyond the above.
+type Block0TailAEAD interface {
+	cipher.AEAD
+
+	// SealWithBlock0Tail behaves like Seal but calls tail with the last 32
+	// bytes of the counter-0 block before returning. tail must not retain
+	// the array, which is zeroed after tail returns.
+	SealWithBlock0Tail(dst, nonce, plaintext, data []byte, tail func(*[32]byte)) []byte
+
+	// OpenWithBlock0Tail behaves like Open but calls tail with the last 32
+	// bytes of the counter-0 block if, and only if, the message is
+	// authentic. tail must not retain the array, which is zeroed after tail
+	// returns.
+	OpenWithBlock0Tail(dst, nonce, ciphertext, data []byte, tail func(*[32]byte)) ([]byte, error)
+}
+
+func (k *chacha20Key) SealWithBlock0Tail(dst, nonce, plaintext, data []byte, tail func(*[32]byte)) []byte {
+	if err := k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	ret := k.seal(c, pk[:32], dst, plaintext, data, nil)
+	k.callTail(&pk, tail)
+	return ret
+}
+
+func (k *chacha20Key) OpenWithBlock0Tail(dst, nonce, ciphertext, data []byte, tail func(*[32]byte)) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	ret, err := k.open(c, pk[:32], dst, ciphertext, tag, data)
+	if err != nil {
+		return nil, err
+	}
+
+	k.callTail(&pk, tail)
+	return ret, nil
+}
+
+// callTail passes the last 32 bytes of the counter-0 block to tail and then
+// zeroes them.
+func (k *chacha20Key) callTail(block *[64]byte, tail func(*[32]byte)) {
+	var t [32]byte
+	copy(t[:], block[32:])
+	wipe(block[32:])
+
+	tail(&t)
+	t = [32]byte{}
+}
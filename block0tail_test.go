@@ -0,0 +1,82 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testBlock0Tail(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	block, err := ChaCha20Block0(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sealTail [32]byte
+	ciphertext := c.(Block0TailAEAD).SealWithBlock0Tail(nil, nonce, plaintext, data, func(tail *[32]byte) {
+		sealTail = *tail
+	})
+
+	if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, ciphertext) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+	}
+
+	if !bytes.Equal(block[32:], sealTail[:]) {
+		t.Errorf("Bad tail: expected %x, was %x", block[32:], sealTail)
+	}
+
+	var openTail [32]byte
+	actual, err := c.(Block0TailAEAD).OpenWithBlock0Tail(nil, nonce, ciphertext, data, func(tail *[32]byte) {
+		openTail = *tail
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if openTail != sealTail {
+		t.Errorf("Bad tail: expected %x, was %x", sealTail, openTail)
+	}
+
+	ciphertext[0] ^= 1
+
+	called := false
+	if _, err := c.(Block0TailAEAD).OpenWithBlock0Tail(nil, nonce, ciphertext, data, func(*[32]byte) {
+		called = true
+	}); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if called {
+		t.Error("Expected tail not to be called for an inauthentic message")
+	}
+}
+
+func TestRFCBlock0Tail(t *testing.T) {
+	testBlock0Tail(t, NewRFC)
+}
+
+func TestDraftBlock0Tail(t *testing.T) {
+	testBlock0Tail(t, NewDraft)
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build go1.13
+// +build go1.13
+
+package chacha20poly1305
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// BenchmarkSealBreakdown performs the same work as Seal with the RFC7539
+// construction, but times the ChaCha20 encryption and the Poly1305 tag
+// computation separately and reports each as a custom metric, in addition to
+// the usual throughput and allocation figures.
+//
+// The two metrics are measured with time.Now around each step and so include
+// a small, constant timer overhead; they are for tracking regressions in
+// each component rather than for comparison with the other benchmarks.
+func BenchmarkSealBreakdown(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			k := new(chacha20Key)
+			nonce := make([]byte, k.NonceSize())
+
+			input := make([]byte, size.l)
+			output := make([]byte, size.l)
+
+			var tag [poly1305.TagSize]byte
+			var encrypt, mac time.Duration
+
+			b.ReportAllocs()
+			b.SetBytes(int64(size.l))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+
+				c := k.stream(nonce)
+
+				var pk [64]byte
+				c.XORKeyStream(pk[:], pk[:])
+				c.XORKeyStream(output, input)
+
+				mid := time.Now()
+
+				k.auth(pk[:32], tag[:], output, nil)
+
+				end := time.Now()
+
+				encrypt += mid.Sub(start)
+				mac += end.Sub(mid)
+			}
+
+			b.ReportMetric(float64(encrypt.Nanoseconds())/float64(b.N), "encrypt-ns/op")
+			b.ReportMetric(float64(mac.Nanoseconds())/float64(b.N), "mac-ns/op")
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrBudgetExceeded is returned by OpenWithBudget when opening the
+// ciphertext would exceed the work budget.
+var ErrBudgetExceeded = errors.New("work budget exceeded")
+
+// BudgetAEAD is implemented by the AEADs returned from NewRFC and NewDraft. It
+// bounds the work a single Open may do.
+type BudgetAEAD interface {
+	cipher.AEAD
+
+	// Blocks returns the work needed to open a ciphertext, including the
+	// tag, of ciphertextLen bytes with dataLen bytes of additional data.
+	// It is the number of 64-byte ChaCha20 blocks, including the block
+	// used to derive the Poly1305 key, plus the number of 16-byte Poly1305
+	// blocks.
+	Blocks(ciphertextLen, dataLen int) uint64
+
+	// OpenWithBudget behaves like Open but first returns
+	// ErrBudgetExceeded, without doing any cryptographic work, if opening
+	// ciphertext would take more than maxBlocks blocks.
+	OpenWithBudget(nonce, ciphertext, data []byte, maxBlocks uint64) ([]byte, error)
+}
+
+func (k *chacha20Key) Blocks(ciphertextLen, dataLen int) uint64 {
+	if ciphertextLen > poly1305.TagSize {
+		ciphertextLen -= poly1305.TagSize
+	} else {
+		ciphertextLen = 0
+	}
+
+	ct, ad := uint64(ciphertextLen), uint64(dataLen)
+
+	var macLen uint64
+	if k.draft {
+		macLen = ad + 8 + ct + 8
+	} else {
+		macLen = roundUp(ad, poly1305PadLen) + roundUp(ct, poly1305PadLen) + 8 + 8
+	}
+
+	return 1 + roundUp(ct, 64)/64 + roundUp(macLen, poly1305PadLen)/poly1305PadLen
+}
+
+func roundUp(n, m uint64) uint64 {
+	return (n + m - 1) / m * m
+}
+
+func (k *chacha20Key) OpenWithBudget(nonce, ciphertext, data []byte, maxBlocks uint64) ([]byte, error) {
+	if k.Blocks(len(ciphertext), len(data)) > maxBlocks {
+		return nil, ErrBudgetExceeded
+	}
+
+	return k.Open(nil, nonce, ciphertext, data)
+}
@@ -0,0 +1,54 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testBudget(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), blocks uint64) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bc := c.(BudgetAEAD)
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := bytes.Repeat([]byte{0x42}, 100)
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	if n := bc.Blocks(len(ciphertext), len(data)); n != blocks {
+		t.Errorf("Expected %d blocks but was %d", blocks, n)
+	}
+
+	actual, err := bc.OpenWithBudget(nonce, ciphertext, data, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if _, err = bc.OpenWithBudget(nonce, ciphertext, data, blocks-1); err != ErrBudgetExceeded {
+		t.Errorf("Expected budget exceeded error but was %v", err)
+	}
+}
+
+func TestRFCBudget(t *testing.T) {
+	// 1 key block + 2 ChaCha20 blocks + (16 + 112 + 16)/16 Poly1305 blocks
+	testBudget(t, NewRFC, 1+2+9)
+}
+
+func TestDraftBudget(t *testing.T) {
+	// 1 key block + 2 ChaCha20 blocks + ceil((10 + 8 + 100 + 8)/16) Poly1305 blocks
+	testBudget(t, NewDraft, 1+2+8)
+}
@@ -0,0 +1,24 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build !purego
+// +build !purego
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"github.com/tmthrgd/chacha20"
+)
+
+// pureGo reports whether the package was built with the purego tag.
+const pureGo = false
+
+// newChaCha20 returns a ChaCha20 cipher for the given key and an 8-byte
+// draft or 12-byte RFC7539 nonce, starting from counter zero. It uses
+// github.com/tmthrgd/chacha20, which has assembly implementations.
+func newChaCha20(key, nonce []byte) (cipher.Stream, error) {
+	return chacha20.New(key, nonce)
+}
@@ -0,0 +1,36 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build purego
+// +build purego
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+// pureGo reports whether the package was built with the purego tag.
+const pureGo = true
+
+// newChaCha20 returns a ChaCha20 cipher for the given key and an 8-byte
+// draft or 12-byte RFC7539 nonce, starting from counter zero. With the purego
+// tag it uses golang.org/x/crypto/chacha20, which honours the tag itself, as
+// does golang.org/x/crypto/poly1305.
+//
+// The draft construction's 64-bit block counter overlaps the first four bytes
+// of the RFC7539 nonce, so a draft nonce is used as an RFC7539 nonce with four
+// leading zero bytes. This is equivalent until the 32-bit counter would
+// overflow, after 256GiB, at which point the cipher panics.
+func newChaCha20(key, nonce []byte) (cipher.Stream, error) {
+	if len(nonce) == DraftNonceSize {
+		rfcNonce := make([]byte, RFCNonceSize)
+		copy(rfcNonce[4:], nonce)
+		nonce = rfcNonce
+	}
+
+	return xchacha20.NewUnauthenticatedCipher(key, nonce)
+}
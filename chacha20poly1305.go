@@ -35,6 +35,22 @@
 // algorithms are resistant to chosen ciphertext attacks, such as padding oracle
 // attacks, etc., and add only 16 bytes of overhead.
 //
+// Seal and Open may be used in place by passing plaintext[:0] or
+// ciphertext[:0] respectively as dst, in which case the output exactly
+// overlaps the input; for Seal, plaintext must have capacity for the tag to
+// avoid a new allocation. Any other overlap between dst and the input
+// panics.
+//
+// As required by cipher.AEAD, Seal and Open both panic with ErrInvalidNonce
+// if the nonce is the wrong size; a wrong-size nonce is a programming error.
+// Other failures in Open, such as a short or forged ciphertext, are returned
+// as errors. SealOrError and OpenOrPanic invert these policies for callers
+// that need to.
+//
+// Building with the purego tag replaces github.com/tmthrgd/chacha20, and its
+// assembly, with golang.org/x/crypto/chacha20, which, like
+// golang.org/x/crypto/poly1305, then uses only its generic Go code.
+//
 // AEAD_CHACHA20_POLY1305 has a significant speed advantage over other AEAD
 // algorithms like AES-GCM, as well as being extremely resistant to timing
 // attacks.
@@ -46,17 +62,34 @@ import (
 	"crypto/subtle"
 	"encoding/binary"
 	"errors"
+	"io"
 	"sync"
 
-	"github.com/tmthrgd/chacha20"
 	"golang.org/x/crypto/poly1305"
 )
 
 const (
 	// KeySize is the required size of ChaCha20 keys.
-	KeySize = chacha20.KeySize
+	KeySize = 32
+
+	// RFCNonceSize is the size of the nonces used by NewRFC.
+	RFCNonceSize = 12
+
+	// DraftNonceSize is the size of the nonces used by NewDraft.
+	DraftNonceSize = 8
+
+	// XNonceSize is the size of the nonces used by NewX.
+	XNonceSize = 24
+
+	// TagSize is the size of the Poly1305 authentication tag.
+	TagSize = poly1305.TagSize
 
 	poly1305PadLen = 16
+
+	// rfcMaxPlaintextLen is the largest plaintext that the RFC7539
+	// construction can seal before its 32-bit block counter, which starts
+	// at one, would wrap.
+	rfcMaxPlaintextLen = (1<<32 - 1) * 64
 )
 
 var (
@@ -69,6 +102,36 @@ var (
 
 	// ErrInvalidNonce is panicked when the provided nonce is the wrong size.
 	ErrInvalidNonce = errors.New("invalid nonce size")
+
+	// ErrWeakKey is returned by NewRFCChecked when the provided key is all
+	// zero.
+	ErrWeakKey = errors.New("weak key")
+
+	// ErrInvalidTagSize is returned when the provided tag is the wrong size.
+	ErrInvalidTagSize = errors.New("invalid tag size")
+
+	// ErrMessageTooLarge is returned when a ciphertext exceeds the maximum
+	// message size of the AEAD.
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrInvalidSalt is returned by NewRFCSalted when the provided salt is the
+	// wrong size.
+	ErrInvalidSalt = errors.New("invalid salt size")
+
+	// ErrCiphertextTooShort is returned by Open when the ciphertext is
+	// too short to contain a tag, and elsewhere when a ciphertext is too
+	// short to contain its required components. Unlike ErrAuthFailed, it
+	// does not indicate tampering; the input is malformed or the message
+	// may simply not have been fully received yet.
+	ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+	// ErrAADTooLarge is returned by Open, and panicked by Seal, when the
+	// additional data exceeds the limit given to NewRFCWithMaxAADLen.
+	ErrAADTooLarge = errors.New("additional data too large")
+
+	// ErrPlaintextTooLong is returned by Open, and panicked by Seal, when a
+	// message is longer than the RFC7539 construction allows.
+	ErrPlaintextTooLong = errors.New("plaintext too long")
 )
 
 // New creates a new AEAD instance using the given key. The key must be exactly
@@ -81,44 +144,161 @@ func New(key []byte) (cipher.AEAD, error) {
 	return NewDraft(key)
 }
 
+// NewCodahaleCompat creates a new AEAD instance using the given key that is
+// compatible with github.com/codahale/chacha20poly1305. It behaves exactly
+// like NewDraft; data sealed by that package can be opened, and vice versa.
+//
+// It exists to make the intent of migrating from that package explicit.
+func NewCodahaleCompat(key []byte) (cipher.AEAD, error) {
+	return NewDraft(key)
+}
+
 // NewRFC creates a new AEAD instance using the given key. The key must be exactly
 // 256 bits long. The returned cipher is an implementation of the RFC7539 AEAD
 // construct.
 func NewRFC(key []byte) (cipher.AEAD, error) {
+	return NewWithOptions(key)
+}
+
+// NewRFCChecked behaves like NewRFC but additionally rejects an all-zero key
+// with ErrWeakKey. An all-zero key is cryptographically valid, but is usually
+// the result of a key buffer that was never filled.
+func NewRFCChecked(key []byte) (cipher.AEAD, error) {
 	if len(key) != KeySize {
 		return nil, ErrInvalidKey
 	}
 
-	k := new(chacha20Key)
+	var zero [KeySize]byte
+	if subtle.ConstantTimeCompare(key, zero[:]) == 1 {
+		return nil, ErrWeakKey
+	}
+
+	return NewRFC(key)
+}
+
+// NewRFCWithMaxLen behaves like NewRFC but the returned cipher's Open method
+// will return ErrMessageTooLarge, without allocating, if the plaintext would be
+// larger than maxLen bytes. A maxLen of zero means no limit.
+func NewRFCWithMaxLen(key []byte, maxLen int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &chacha20Key{maxLen: maxLen}
 	copy(k.key[:], key)
 	return k, nil
 }
 
-// NewDraft creates a new AEAD instance using the given key. The key must be
-// exactly 256 bits long. The returned cipher is an implementation of the
-// draft-agl-tls-chacha20poly1305-03 AEAD construct.
-func NewDraft(key []byte) (cipher.AEAD, error) {
+// NewRFCWithMaxAADLen behaves like NewRFC but the returned cipher's Seal
+// method will panic, and its Open method return, ErrAADTooLarge if the
+// additional data is longer than maxAADLen bytes. The limit applies equally to
+// every other seal and open method of the cipher, such as SealDetached and
+// SealReader. A maxAADLen of zero means no limit. This allows strict
+// conformance with peers that can't encode larger lengths, such as those
+// limited to 2^32-1 bytes.
+func NewRFCWithMaxAADLen(key []byte, maxAADLen uint64) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &chacha20Key{maxAADLen: maxAADLen}
+	copy(k.key[:], key)
+	return k, nil
+}
+
+// NewRFCWithCompare behaves like NewRFC but the returned cipher's Open method
+// uses compare, rather than subtle.ConstantTimeCompare, to check the tag.
+// compare must have the same semantics as subtle.ConstantTimeCompare and must
+// run in constant time. This allows an audited or fault-resistant comparison
+// to be substituted.
+func NewRFCWithCompare(key []byte, compare func(x, y []byte) int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &chacha20Key{compare: compare}
+	copy(k.key[:], key)
+	return k, nil
+}
+
+// NewRFCNoPool behaves like NewRFC but the returned cipher does not use the
+// package's internal buffer pool, allocating fresh scratch space for each call
+// instead. This is simpler for short-lived processes that only seal or open a
+// handful of messages, where the pool provides no benefit.
+func NewRFCNoPool(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &chacha20Key{noPool: true}
+	copy(k.key[:], key)
+	return k, nil
+}
+
+// NewRFCSalted behaves like NewRFC but the returned cipher XORs salt, which
+// must be exactly 12 bytes long, into every nonce passed to Seal and Open.
+// Under the same key, two instances with different salts map the same caller
+// nonce to different ChaCha20 nonces. This does not separate them entirely:
+// nonce n under salt A and nonce n^A^B under salt B are the same ChaCha20
+// nonce, so nonces must still be unique across every instance sharing a key.
+//
+// The salt must be shared out-of-band with the recipient, like the key.
+func NewRFCSalted(key, salt []byte) (cipher.AEAD, error) {
 	if len(key) != KeySize {
 		return nil, ErrInvalidKey
 	}
 
-	k := &chacha20Key{draft: true}
+	if len(salt) != RFCNonceSize {
+		return nil, ErrInvalidSalt
+	}
+
+	k := &chacha20Key{salted: true}
 	copy(k.key[:], key)
+	copy(k.salt[:], salt)
 	return k, nil
 }
 
+// NewDraft creates a new AEAD instance using the given key. The key must be
+// exactly 256 bits long. The returned cipher is an implementation of the
+// draft-agl-tls-chacha20poly1305-03 AEAD construct.
+func NewDraft(key []byte) (cipher.AEAD, error) {
+	return NewWithOptions(key, WithMode(ModeDraft))
+}
+
 type chacha20Key struct {
-	key [chacha20.KeySize]byte
+	// key is never written after construction, other than by Destroy
+	// and UnmarshalBinary, so that Seal and Open may be called
+	// concurrently from multiple goroutines.
+	key [KeySize]byte
+
+	destroyed bool // set by Destroy
 
 	draft bool // draft or RFC
+
+	maxLen int // maximum plaintext length for Open, zero if unlimited
+
+	maxAADLen uint64 // maximum additional data length, zero if unlimited
+
+	noPool bool // don't use authPool or keyBlockPool
+
+	salted bool               // whether salt is XORed into nonces
+	salt   [RFCNonceSize]byte // only used by the RFC construction
+
+	// counter is the block counter that encryption starts from, if
+	// greater than one. Only used by the RFC construction.
+	counter uint32
+
+	// compare is used to compare tags in Open, if nil
+	// subtle.ConstantTimeCompare is used.
+	compare func(x, y []byte) int
 }
 
 func (k *chacha20Key) NonceSize() int {
 	if k.draft {
-		return chacha20.DraftNonceSize
+		return DraftNonceSize
 	}
 
-	return chacha20.RFCNonceSize
+	return RFCNonceSize
 }
 
 func (*chacha20Key) Overhead() int {
@@ -126,52 +306,263 @@ func (*chacha20Key) Overhead() int {
 }
 
 func (k *chacha20Key) Seal(dst, nonce, plaintext, data []byte) []byte {
-	if len(nonce) != k.NonceSize() {
-		panic(ErrInvalidNonce)
+	ret, err := k.sealSafe(1, dst, nonce, plaintext, data)
+	if err != nil {
+		panic(err)
 	}
 
-	c, err := chacha20.New(k.key[:], nonce)
-	if err != nil {
-		panic(err) // basically impossible
+	return ret
+}
+
+func (k *chacha20Key) SealSafe(dst, nonce, plaintext, data []byte) ([]byte, error) {
+	return k.sealSafe(1, dst, nonce, plaintext, data)
+}
+
+// sealSafe implements Seal and SealSafe. calldepth is passed on to checkDst.
+func (k *chacha20Key) sealSafe(calldepth int, dst, nonce, plaintext, data []byte) ([]byte, error) {
+	if err := k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		return nil, err
 	}
 
+	checkDst(calldepth+1, dst, plaintext, k.Overhead())
+
+	c := k.stream(nonce)
+
+	if len(plaintext) <= smallSealLen {
+		return k.sealSmall(c, dst, plaintext, data), nil
+	}
+
+	pk := k.keyBlock(c)
+	defer k.putKeyBlock(pk)
+
+	return k.seal(c, pk[:32], dst, plaintext, data, nil), nil
+}
+
+// smallSealLen is the largest plaintext that sealSmall handles.
+const smallSealLen = 64
+
+// sealSmall is an optimised version of seal for short messages. It generates
+// the counter-0 block and the keystream for plaintext in a single call to
+// XORKeyStream, halving the fixed per-call overhead of the cipher.
+func (k *chacha20Key) sealSmall(c cipher.Stream, dst, plaintext, data []byte) []byte {
 	ret, out := sliceForAppend(dst, len(plaintext)+poly1305.TagSize)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
 
-	var pk [64]byte
-	c.XORKeyStream(pk[:], pk[:])
+	var buf [64 + smallSealLen]byte
+	n := 64 + copy(buf[64:], plaintext)
+	c.XORKeyStream(buf[:n], buf[:n])
+
+	copy(out, buf[64:n])
 
-	c.XORKeyStream(out, plaintext)
+	k.auth(buf[:32], out[len(plaintext):], out[:len(plaintext)], data)
 
-	k.auth(pk[:32], out[len(plaintext):], out[:len(plaintext)], data)
+	// buf holds the Poly1305 key and the rest of the counter-0 block.
+	wipe(buf[:])
 	return ret
 }
 
+// IsComplete reports whether ciphertext is at least c.Overhead() bytes long,
+// and so may be passed to c.Open without it returning ErrCiphertextTooShort.
+// A streaming receiver can use this to decide whether to buffer more data.
+func IsComplete(c cipher.AEAD, ciphertext []byte) bool {
+	return len(ciphertext) >= c.Overhead()
+}
+
 func (k *chacha20Key) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	c := k.stream(nonce)
+
+	pk := k.keyBlock(c)
+	defer k.putKeyBlock(pk)
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	return k.open(c, pk[:32], dst, ciphertext, tag, data)
+}
+
+// checkSeal returns the error, if any, that sealing an n byte plaintext with
+// dataLen bytes of additional data should fail with. Every seal entry point
+// calls it before deriving the Poly1305 key.
+func (k *chacha20Key) checkSeal(nonce []byte, n int, dataLen uint64) error {
+	if len(nonce) != k.NonceSize() {
+		return ErrInvalidNonce
+	}
+
+	if k.destroyed {
+		return ErrInvalidKey
+	}
+
+	if k.plaintextTooLong(uint64(n)) {
+		return ErrPlaintextTooLong
+	}
+
+	if k.aadTooLarge(dataLen) {
+		return ErrAADTooLarge
+	}
+
+	return nil
+}
+
+// checkOpen returns the error, if any, that opening an n byte ciphertext, of
+// which overhead bytes are not plaintext, with dataLen bytes of additional
+// data should fail with. Every open entry point calls it before deriving the
+// Poly1305 key. Like Open, it panics with ErrInvalidNonce if nonce is the
+// wrong size.
+func (k *chacha20Key) checkOpen(nonce []byte, n, overhead int, dataLen uint64) error {
 	if len(nonce) != k.NonceSize() {
 		panic(ErrInvalidNonce)
 	}
 
-	if len(ciphertext) < poly1305.TagSize {
-		return nil, ErrAuthFailed
+	if k.destroyed {
+		return ErrInvalidKey
 	}
 
-	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
-	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+	if n < overhead {
+		return ErrCiphertextTooShort
+	}
+
+	if k.maxLen > 0 && n-overhead > k.maxLen {
+		return ErrMessageTooLarge
+	}
+
+	if k.plaintextTooLong(uint64(n - overhead)) {
+		return ErrPlaintextTooLong
+	}
+
+	if k.aadTooLarge(dataLen) {
+		return ErrAADTooLarge
+	}
+
+	return nil
+}
+
+// plaintextTooLong reports whether an n byte plaintext exceeds the limit of
+// the RFC7539 construction. The draft construction has a 64-bit block counter
+// and so has no practical limit.
+func (k *chacha20Key) plaintextTooLong(n uint64) bool {
+	if k.counter > 1 {
+		return n > (1<<32-uint64(k.counter))*64
+	}
+
+	return !k.draft && n > rfcMaxPlaintextLen
+}
+
+// aadTooLarge reports whether n bytes of additional data exceeds maxAADLen.
+func (k *chacha20Key) aadTooLarge(n uint64) bool {
+	return k.maxAADLen > 0 && n > k.maxAADLen
+}
+
+// stream returns a ChaCha20 cipher for nonce, positioned at the start of the
+// counter-0 block.
+//
+// A new cipher is created for each call rather than being cached on k.
+// ChaCha20 has no key schedule to amortise: creating a cipher only loads the
+// key, counter and nonce into its initial state. github.com/tmthrgd/chacha20
+// also provides no way to reset the nonce of an existing cipher, and sharing
+// one would break concurrent use of Seal and Open.
+func (k *chacha20Key) stream(nonce []byte) cipher.Stream {
+	if k.destroyed {
+		panic(ErrInvalidKey)
+	}
+
+	nonce = k.saltNonce(nonce)
 
-	c, err := chacha20.New(k.key[:], nonce)
+	if k.counter > 1 {
+		return newCounterStream(k.key[:], nonce, k.counter)
+	}
+
+	c, err := newChaCha20(k.key[:], nonce)
 	if err != nil {
 		panic(err) // basically impossible
 	}
 
-	var pk [64]byte
-	c.XORKeyStream(pk[:], pk[:])
+	return c
+}
 
-	var expectedTag [poly1305.TagSize]byte
-	k.auth(pk[:32], expectedTag[:], ciphertext, data)
+// saltNonce returns nonce XORed with the salt if k was created by
+// NewRFCSalted, and nonce unchanged otherwise.
+func (k *chacha20Key) saltNonce(nonce []byte) []byte {
+	if !k.salted {
+		return nonce
+	}
+
+	salted := make([]byte, RFCNonceSize)
+	for i := range salted {
+		salted[i] = nonce[i] ^ k.salt[i]
+	}
+
+	return salted
+}
+
+// seal encrypts plaintext with c, which must be positioned at the start of the
+// counter-1 block, and authenticates it with the one-time Poly1305 key pk.
+//
+// The plaintext is processed in chunks of sealChunkSize bytes, each of which
+// is authenticated immediately after it is encrypted, while it is still in
+// cache, so that the ciphertext is only passed over once. If tee is not nil,
+// each chunk of ciphertext, and then the tag, is also written to it in the
+// same pass.
+func (k *chacha20Key) seal(c cipher.Stream, pk, dst, plaintext, data []byte, tee io.Writer) []byte {
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305.TagSize)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	mac := k.newMACWriter(pk)
+	mac.writeData(data)
+	mac.endData()
+
+	for i := 0; i < len(plaintext); i += sealChunkSize {
+		end := i + sealChunkSize
+		if end > len(plaintext) {
+			end = len(plaintext)
+		}
+
+		chunk := out[i:end]
+		c.XORKeyStream(chunk, plaintext[i:end])
+		mac.Write(chunk)
+
+		if tee != nil {
+			tee.Write(chunk)
+		}
+	}
+
+	tag := out[len(plaintext):]
+	mac.sum(tag)
+
+	if tee != nil {
+		tee.Write(tag)
+	}
+
+	return ret
+}
 
+// sealChunkSize is the amount of plaintext that seal encrypts before
+// authenticating it.
+const sealChunkSize = 4 * 1024
+
+// open authenticates ciphertext against tag with the one-time Poly1305 key pk
+// and decrypts it with c, which must be positioned at the start of the
+// counter-1 block.
+func (k *chacha20Key) open(c cipher.Stream, pk, dst, ciphertext, tag, data []byte) ([]byte, error) {
+	return k.decryptVerified(c, dst, ciphertext, k.verifyTag(pk, ciphertext, tag, data))
+}
+
+// decryptVerified decrypts ciphertext with c, appending the plaintext to dst,
+// if verified is true. Otherwise it returns ErrAuthFailed.
+func (k *chacha20Key) decryptVerified(c cipher.Stream, dst, ciphertext []byte, verified bool) ([]byte, error) {
 	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic(errInvalidOverlap)
+	}
 
-	if subtle.ConstantTimeCompare(expectedTag[:], tag) != 1 {
+	if !verified {
 		// The AESNI code decrypts and authenticates concurrently, and
 		// so overwrites dst in the event of a tag mismatch. That
 		// behaviour is mimicked here in order to be consistent across
@@ -187,6 +578,58 @@ func (k *chacha20Key) Open(dst, nonce, ciphertext, data []byte) ([]byte, error)
 	return ret, nil
 }
 
+// verifyTag reports whether tag is the Poly1305 tag of ciphertext and data
+// under the one-time key pk.
+func (k *chacha20Key) verifyTag(pk, ciphertext, tag, data []byte) bool {
+	var expectedTag [poly1305.TagSize]byte
+	k.auth(pk, expectedTag[:], ciphertext, data)
+	return k.tagEqual(expectedTag[:], tag)
+}
+
+// tagEqual reports whether tag equals expectedTag, using the compare function
+// given to NewRFCWithCompare if there is one.
+func (k *chacha20Key) tagEqual(expectedTag, tag []byte) bool {
+	compare := subtle.ConstantTimeCompare
+	if k.compare != nil {
+		compare = k.compare
+	}
+
+	return compare(expectedTag, tag) == 1
+}
+
+// keyBlockPool holds zeroed buffers for the counter-0 keystream block. As
+// the block is passed to the cipher through an interface, it would otherwise
+// escape to the heap on every call.
+var keyBlockPool = &sync.Pool{
+	New: func() interface{} {
+		return new([64]byte)
+	},
+}
+
+// keyBlock returns the counter-0 keystream block from c, which must be
+// positioned at its start. It must be released with putKeyBlock.
+func (k *chacha20Key) keyBlock(c cipher.Stream) *[64]byte {
+	var pk *[64]byte
+	if k.noPool {
+		pk = new([64]byte)
+	} else {
+		pk = keyBlockPool.Get().(*[64]byte)
+	}
+
+	c.XORKeyStream(pk[:], pk[:])
+	return pk
+}
+
+// putKeyBlock zeroes pk, so that no key material is retained, and returns it
+// to keyBlockPool.
+func (k *chacha20Key) putKeyBlock(pk *[64]byte) {
+	*pk = [64]byte{}
+
+	if !k.noPool {
+		keyBlockPool.Put(pk)
+	}
+}
+
 var authPool = &sync.Pool{
 	New: func() interface{} {
 		return new(bytes.Buffer)
@@ -194,20 +637,55 @@ var authPool = &sync.Pool{
 }
 
 func (k *chacha20Key) auth(key, out, ciphertext, data []byte) {
+	if k.noPool {
+		var m bytes.Buffer
+		k.authBuffer(&m, key, out, ciphertext, data)
+		return
+	}
+
 	m := authPool.Get().(*bytes.Buffer)
+	k.authBuffer(m, key, out, ciphertext, data)
+	authPool.Put(m)
+}
+
+// authBuffer computes the tag as auth does, using m as scratch space for the
+// MAC input.
+func (k *chacha20Key) authBuffer(m *bytes.Buffer, key, out, ciphertext, data []byte) {
 	m.Reset()
+	writeMACInput(m, ciphertext, data, k.draft)
 
-	if k.draft {
+	var pkey [32]byte
+	copy(pkey[:], key)
+
+	var mac [poly1305.TagSize]byte
+	poly1305.Sum(&mac, m.Bytes(), &pkey)
+
+	copy(out, mac[:])
+	return
+}
+
+// MACInput returns the exact byte sequence that is authenticated by Poly1305
+// for the given ciphertext and additional data, in either the draft or RFC
+// construction. It is intended for debugging tag mismatches against other
+// implementations.
+func MACInput(ciphertext, data []byte, draft bool) []byte {
+	var m bytes.Buffer
+	writeMACInput(&m, ciphertext, data, draft)
+	return m.Bytes()
+}
+
+func writeMACInput(m *bytes.Buffer, ciphertext, data []byte, draft bool) {
+	if draft {
 		m.Grow(len(data) + 8 + len(ciphertext) + 8)
 
 		m.Write(data)
-		binary.Write(m, binary.LittleEndian, uint64(len(data)))
+		writeUint64(m, uint64(len(data)))
 
 		m.Write(ciphertext)
-		binary.Write(m, binary.LittleEndian, uint64(len(ciphertext)))
+		writeUint64(m, uint64(len(ciphertext)))
 	} else {
-		dPad := (poly1305PadLen - (len(data) % poly1305PadLen)) % poly1305PadLen
-		cPad := (poly1305PadLen - (len(ciphertext) % poly1305PadLen)) % poly1305PadLen
+		dPad := Pad16Len(len(data))
+		cPad := Pad16Len(len(ciphertext))
 
 		m.Grow(len(data) + dPad + len(ciphertext) + cPad + 8 + 8)
 
@@ -219,20 +697,24 @@ func (k *chacha20Key) auth(key, out, ciphertext, data []byte) {
 		m.Write(ciphertext)
 		m.Write(zero[:cPad])
 
-		binary.Write(m, binary.LittleEndian, uint64(len(data)))
-		binary.Write(m, binary.LittleEndian, uint64(len(ciphertext)))
+		writeUint64(m, uint64(len(data)))
+		writeUint64(m, uint64(len(ciphertext)))
 	}
+}
 
-	var pkey [32]byte
-	copy(pkey[:], key)
-
-	var mac [poly1305.TagSize]byte
-	poly1305.Sum(&mac, m.Bytes(), &pkey)
-
-	authPool.Put(m)
+// Pad16Len returns the number of zero bytes needed to pad n bytes to a
+// multiple of 16, as the RFC7539 construction does for the additional data
+// and the ciphertext.
+func Pad16Len(n int) int {
+	return (poly1305PadLen - (n % poly1305PadLen)) % poly1305PadLen
+}
 
-	copy(out, mac[:])
-	return
+// writeUint64 writes v to m as an 8-byte, little-endian value. Unlike
+// binary.Write it does not allocate.
+func writeUint64(m *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	m.Write(b[:])
 }
 
 // sliceForAppend takes a slice and a requested number of bytes. It returns a
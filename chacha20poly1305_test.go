@@ -7,16 +7,20 @@ package chacha20poly1305
 import (
 	"bytes"
 	"crypto/cipher"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
 	"reflect"
+	"sync"
 	"testing"
 	"testing/quick"
 
 	codahale "github.com/codahale/chacha20poly1305"
 	"github.com/tmthrgd/chacha20"
 	"github.com/tmthrgd/poly1305"
+	xcrypto "golang.org/x/crypto/chacha20poly1305"
 )
 
 func mustHexDecode(v string) []byte {
@@ -288,7 +292,7 @@ func testSealInvalidNonce(t *testing.T, newChaCha20Poly1305 func(key []byte) (ci
 
 	defer func() {
 		if r := recover(); r != ErrInvalidNonce {
-			t.Errorf("Expected invalid key panic but was %v", r)
+			t.Errorf("Expected invalid nonce panic but was %v", r)
 		}
 	}()
 
@@ -318,7 +322,7 @@ func testOpenInvalidNonce(t *testing.T, newChaCha20Poly1305 func(key []byte) (ci
 
 	defer func() {
 		if r := recover(); r != ErrInvalidNonce {
-			t.Errorf("Expected invalid key panic but was %v", r)
+			t.Errorf("Expected invalid nonce panic but was %v", r)
 		}
 	}()
 
@@ -348,8 +352,20 @@ func testOpenTooShort(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher
 
 	_, err = c.Open(nil, nonce, ciphertext[:2], data)
 
-	if err != ErrAuthFailed {
-		t.Errorf("Expected message authentication failed error but was %v", err)
+	if err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+
+	if IsComplete(c, ciphertext[:2]) {
+		t.Error("Expected truncated ciphertext to be incomplete")
+	}
+
+	if IsComplete(c, ciphertext[:c.Overhead()-1]) {
+		t.Error("Expected ciphertext shorter than the overhead to be incomplete")
+	}
+
+	if !IsComplete(c, ciphertext[:c.Overhead()]) {
+		t.Error("Expected ciphertext with a tag to be complete")
 	}
 }
 
@@ -361,6 +377,14 @@ func TestDraftOpenTooShort(t *testing.T) {
 	testOpenTooShort(t, NewDraft)
 }
 
+func TestRFCDualTagOpenTooShort(t *testing.T) {
+	testOpenTooShort(t, NewRFCDualTag)
+}
+
+func TestRFCCommittingOpenTooShort(t *testing.T) {
+	testOpenTooShort(t, NewRFCCommitting)
+}
+
 func testTagFailureOverwrite(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), vector testVector) {
 	// The AESNI GCM code decrypts and authenticates concurrently and so
 	// overwrites the output buffer before checking the authentication tag.
@@ -486,3 +510,894 @@ func ExampleNewDraft() {
 	fmt.Printf("%x\n", ciphertext)
 	// Output: e6669e9e333e4a5af5df2b8d1669cbdc175bb32da46484e6e358
 }
+
+func testConcurrent(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			nonce := make([]byte, c.NonceSize())
+			nonce[0] = byte(i)
+
+			for j := 0; j < 100; j++ {
+				ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+				actual, err := c.Open(nil, nonce, ciphertext, data)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				if !bytes.Equal(plaintext, actual) {
+					t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestRFCConcurrent(t *testing.T) {
+	testConcurrent(t, NewRFC)
+}
+
+func TestDraftConcurrent(t *testing.T) {
+	testConcurrent(t, NewDraft)
+}
+
+func TestRFCMaxLen(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFCWithMaxLen(key, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	plaintext := []byte("yay for me")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	actual, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	ciphertext = c.Seal(nil, nonce, []byte("yay for me!"), data)
+
+	if _, err = c.Open(nil, nonce, ciphertext, data); err != ErrMessageTooLarge {
+		t.Errorf("Expected message too large error but was %v", err)
+	}
+
+	if _, err = NewRFCWithMaxLen(key[:31], 10); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func testCrossConstruction(t *testing.T, seal, open func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	sc, err := seal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oc, err := open(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, chacha20.RFCNonceSize)
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := sc.Seal(nil, nonce[:sc.NonceSize()], plaintext, data)
+
+	if _, err = oc.Open(nil, nonce[:oc.NonceSize()], ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrInvalidNonce {
+			t.Errorf("Expected invalid nonce panic but was %v", r)
+		}
+	}()
+
+	oc.Open(nil, nonce[:sc.NonceSize()], ciphertext, data)
+}
+
+func TestRFCSealDraftOpen(t *testing.T) {
+	testCrossConstruction(t, NewRFC, NewDraft)
+}
+
+func TestDraftSealRFCOpen(t *testing.T) {
+	testCrossConstruction(t, NewDraft, NewRFC)
+}
+
+func TestMACInput(t *testing.T) {
+	ciphertext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	for _, test := range []struct {
+		draft    bool
+		expected []byte
+	}{
+		{true, mustHexDecode("77686f616820796561680a00000000000000" +
+			"79617920666f72206d650a00000000000000")},
+		{false, mustHexDecode("77686f61682079656168000000000000" +
+			"79617920666f72206d65000000000000" +
+			"0a000000000000000a00000000000000")},
+	} {
+		if actual := MACInput(ciphertext, data, test.draft); !bytes.Equal(test.expected, actual) {
+			t.Errorf("Bad MAC input (draft=%t): expected %x, was %x", test.draft, test.expected, actual)
+		}
+	}
+}
+
+func TestRFCChecked(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	if _, err := NewRFCChecked(key); err != ErrWeakKey {
+		t.Errorf("Expected weak key error but was %v", err)
+	}
+
+	if _, err := NewRFCChecked(key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	key[KeySize-1] = 1
+
+	if _, err := NewRFCChecked(key); err != nil {
+		t.Error(err)
+	}
+}
+
+func testSealSmall(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for l := 0; l <= 2*smallSealLen+1; l++ {
+		plaintext := bytes.Repeat([]byte{0x42}, l)
+
+		ciphertext, tag := c.(DetachedAEAD).SealDetached(nil, nonce, plaintext, data)
+		expected := append(ciphertext, tag[:]...)
+
+		if actual := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual)
+		}
+	}
+}
+
+func TestRFCSealSmall(t *testing.T) {
+	testSealSmall(t, NewRFC)
+}
+
+func TestDraftSealSmall(t *testing.T) {
+	testSealSmall(t, NewDraft)
+}
+
+func testSinglePassSeal(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := c.(*chacha20Key)
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	r := rand.New(rand.NewSource(1))
+	sizes := []int{smallSealLen + 1, sealChunkSize - 1, sealChunkSize, sealChunkSize + 1, 3 * sealChunkSize}
+	for i := 0; i < 50; i++ {
+		sizes = append(sizes, smallSealLen+1+r.Intn(4*sealChunkSize))
+	}
+
+	for _, l := range sizes {
+		plaintext := make([]byte, l)
+		r.Read(plaintext)
+
+		// Encrypt the whole plaintext, then authenticate the whole
+		// ciphertext, in two passes.
+		stream := k.stream(nonce)
+
+		var pk [64]byte
+		stream.XORKeyStream(pk[:], pk[:])
+
+		expected := make([]byte, l+poly1305.TagSize)
+		stream.XORKeyStream(expected[:l], plaintext)
+		k.auth(pk[:32], expected[l:], expected[:l], data)
+
+		if actual := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual)
+		}
+	}
+}
+
+func TestRFCSinglePassSeal(t *testing.T) {
+	testSinglePassSeal(t, NewRFC)
+}
+
+func TestDraftSinglePassSeal(t *testing.T) {
+	testSinglePassSeal(t, NewDraft)
+}
+
+func testPlaintextIsKeystream(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	block, err := ChaCha20Block0(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range []int{1, smallSealLen, 1000} {
+		// Sealing zeros yields the keystream as the ciphertext body.
+		plaintext := c.Seal(nil, nonce, make([]byte, l), data)[:l]
+
+		actual := c.Seal(nil, nonce, plaintext, data)
+
+		zero := make([]byte, l)
+		if !bytes.Equal(zero, actual[:l]) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, zero, actual[:l])
+		}
+
+		var tag [poly1305.TagSize]byte
+		c.(*chacha20Key).auth(block[:32], tag[:], zero, data)
+
+		if !bytes.Equal(tag[:], actual[l:]) {
+			t.Errorf("Bad tag of %d bytes: expected %x, was %x", l, tag, actual[l:])
+		}
+
+		opened, err := c.Open(nil, nonce, actual, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Errorf("Bad open of %d bytes: expected %x, was %x", l, plaintext, opened)
+		}
+	}
+}
+
+func TestRFCPlaintextIsKeystream(t *testing.T) {
+	testPlaintextIsKeystream(t, NewRFC)
+}
+
+func TestDraftPlaintextIsKeystream(t *testing.T) {
+	testPlaintextIsKeystream(t, NewDraft)
+}
+
+func testOpenOverlap(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), vector testVector) {
+	c, err := newChaCha20Poly1305(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ciphertext := append([]byte(nil), vector.ciphertext...)
+
+	actual, err := c.Open(ciphertext[:0], vector.nonce, ciphertext, vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+	}
+
+	ciphertext = append(ciphertext[:0], vector.ciphertext...)
+
+	defer func() {
+		if r := recover(); r != errInvalidOverlap {
+			t.Errorf("Expected invalid overlap panic but was %v", r)
+		}
+	}()
+
+	c.Open(ciphertext[1:1], vector.nonce, ciphertext, vector.data)
+}
+
+func TestRFCOpenOverlap(t *testing.T) {
+	testOpenOverlap(t, NewRFC, rfcTestVectors[0])
+}
+
+func TestDraftOpenOverlap(t *testing.T) {
+	testOpenOverlap(t, NewDraft, draftTestVectors[0])
+}
+
+func testInPlace(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, l := range []int{1, smallSealLen, smallSealLen + 1, 1000} {
+		plaintext := bytes.Repeat([]byte{0x42}, l)
+		expected := c.Seal(nil, nonce, plaintext, data)
+
+		buf := make([]byte, l, l+c.Overhead())
+		copy(buf, plaintext)
+
+		ciphertext := c.Seal(buf[:0], nonce, buf, data)
+		if !bytes.Equal(expected, ciphertext) {
+			t.Errorf("Bad in-place seal of %d bytes: expected %x, was %x", l, expected, ciphertext)
+		}
+
+		if &ciphertext[0] != &buf[0] {
+			t.Errorf("Seal of %d bytes did not encrypt in place", l)
+		}
+
+		actual, err := c.Open(ciphertext[:0], nonce, ciphertext, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad in-place open of %d bytes: expected %x, was %x", l, plaintext, actual)
+		}
+
+		if &actual[0] != &buf[0] {
+			t.Errorf("Open of %d bytes did not decrypt in place", l)
+		}
+	}
+}
+
+func TestRFCInPlace(t *testing.T) {
+	testInPlace(t, NewRFC)
+}
+
+func TestDraftInPlace(t *testing.T) {
+	testInPlace(t, NewDraft)
+}
+
+func TestXInPlace(t *testing.T) {
+	testInPlace(t, NewX)
+}
+
+func TestRFCCompare(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	var calls int
+	c, err := NewRFCWithCompare(key, func(x, y []byte) int {
+		calls++
+		return 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	if _, err = c.Open(nil, nonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected compare to be invoked once but was invoked %d times", calls)
+	}
+
+	if _, err = NewRFCWithCompare(key[:31], nil); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func testAuthPool(t *testing.T, draft bool) {
+	k := &chacha20Key{draft: draft}
+
+	var pk [32]byte
+	for i := range pk {
+		pk[i] = byte(i)
+	}
+
+	inputs := []struct {
+		ciphertext, data []byte
+	}{
+		{bytes.Repeat([]byte{0x01}, 1024), []byte("whoah yeah")},
+		{[]byte("yay for me"), nil},
+		{nil, bytes.Repeat([]byte{0x02}, 4096)},
+		{[]byte("a"), []byte("b")},
+	}
+
+	tags := make([][poly1305.TagSize]byte, len(inputs))
+	for i, in := range inputs {
+		k.auth(pk[:], tags[i][:], in.ciphertext, in.data)
+	}
+
+	for j := 0; j < 3; j++ {
+		for i := len(inputs) - 1; i >= 0; i-- {
+			var tag [poly1305.TagSize]byte
+			k.auth(pk[:], tag[:], inputs[i].ciphertext, inputs[i].data)
+
+			if tag != tags[i] {
+				t.Errorf("Bad tag for input %d: expected %x, was %x", i, tags[i], tag)
+			}
+		}
+	}
+}
+
+func TestRFCAuthPool(t *testing.T) {
+	testAuthPool(t, false)
+}
+
+func TestDraftAuthPool(t *testing.T) {
+	testAuthPool(t, true)
+}
+
+func TestRFCNoPool(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		c, err := NewRFCNoPool(vector.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := c.Seal(nil, vector.nonce, vector.plaintext, vector.data); !bytes.Equal(vector.ciphertext, actual) {
+			t.Errorf("Bad seal: expected %x, was %x", vector.ciphertext, actual)
+		}
+
+		actual, err := c.Open(nil, vector.nonce, vector.ciphertext, vector.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(vector.plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+		}
+	}
+
+	if _, err := NewRFCNoPool(make([]byte, 31)); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestRFCMaxAADLen(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFCWithMaxAADLen(key, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	actual, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	long := append(data, '!')
+
+	if _, err := c.Open(nil, nonce, ciphertext, long); err != ErrAADTooLarge {
+		t.Errorf("Expected additional data too large error but was %v", err)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r != ErrAADTooLarge {
+				t.Errorf("Expected additional data too large panic but was %v", r)
+			}
+		}()
+
+		c.Seal(nil, nonce, plaintext, long)
+	}()
+
+	for name, err := range entryPointErrors(c, plaintext, long) {
+		if err != ErrAADTooLarge {
+			t.Errorf("%s: expected additional data too large error but was %v", name, err)
+		}
+	}
+
+	// Check the 2^32-1 limit without allocating 4GiB of data.
+	k := &chacha20Key{maxAADLen: 1<<32 - 1}
+	if k.aadTooLarge(1<<32 - 1) {
+		t.Error("Expected 2^32-1 bytes of additional data to be allowed")
+	}
+
+	if !k.aadTooLarge(1 << 32) {
+		t.Error("Expected 2^32 bytes of additional data to be too large")
+	}
+
+	if _, err := NewRFCWithMaxAADLen(key[:31], 10); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestPlaintextTooLong(t *testing.T) {
+	// Check the limit without allocating 256GiB of plaintext.
+	rfc := new(chacha20Key)
+	if rfc.plaintextTooLong((1<<32 - 1) * 64) {
+		t.Error("Expected (2^32-1)*64 bytes of plaintext to be allowed")
+	}
+
+	if !rfc.plaintextTooLong((1<<32-1)*64 + 1) {
+		t.Error("Expected (2^32-1)*64+1 bytes of plaintext to be too long")
+	}
+
+	draft := &chacha20Key{draft: true}
+	if draft.plaintextTooLong((1<<32-1)*64 + 1) {
+		t.Error("Expected the draft construction to have no plaintext limit")
+	}
+}
+
+func TestRFCSalted(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, 12)
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	saltA := bytes.Repeat([]byte{1}, 12)
+	saltB := bytes.Repeat([]byte{2}, 12)
+
+	a, err := NewRFCSalted(key, saltA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewRFCSalted(key, saltB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctA := a.Seal(nil, nonce, plaintext, data)
+	ctB := b.Seal(nil, nonce, plaintext, data)
+
+	if bytes.Equal(ctA, ctB) {
+		t.Errorf("Expected different ciphertexts under different salts, both were %x", ctA)
+	}
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := c.Seal(nil, saltA, plaintext, data); !bytes.Equal(expected, ctA) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ctA)
+	}
+
+	actual, err := a.Open(nil, nonce, ctA, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if _, err := b.Open(nil, nonce, ctA, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err := NewRFCSalted(make([]byte, 31), saltA); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	if _, err := NewRFCSalted(key, saltA[:8]); err != ErrInvalidSalt {
+		t.Errorf("Expected invalid salt error but was %v", err)
+	}
+}
+
+func Example_reuseBuffer() {
+	key := readSecretKey(KeySize) // must be 256 bits long
+
+	c, err := NewRFC(key)
+	if err != nil {
+		panic(err)
+	}
+
+	nonce := readRandomNonce(c.NonceSize()) // must be generated by crypto/rand
+	data := []byte("whoah yeah")
+
+	// Seal appends to dst, so buf must be reset with buf[:0] before each
+	// call to reuse its storage. Passing buf itself would append the new
+	// message after the previous one.
+	var buf []byte
+	for _, plaintext := range []string{"yay for me", "yay for you"} {
+		buf = c.Seal(buf[:0], nonce, []byte(plaintext), data)
+		fmt.Println(len(buf))
+
+		nonce[0]++
+	}
+	// Output:
+	// 26
+	// 27
+}
+
+func TestRFCBlockBoundary(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := xcrypto.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	// The Poly1305 key uses counter 0 and the payload starts at counter 1,
+	// an off-by-one would only show at particular lengths.
+	for _, l := range []int{63, 64, 65, 127, 128, 129} {
+		plaintext := bytes.Repeat([]byte{0x42}, l)
+
+		expected := x.Seal(nil, nonce, plaintext, data)
+		if actual := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual)
+		}
+
+		actual, err := c.Open(nil, nonce, expected, data)
+		if err != nil {
+			t.Errorf("Failed to open %d bytes: %v", l, err)
+		} else if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open of %d bytes: expected %x, was %x", l, plaintext, actual)
+		}
+	}
+}
+
+func TestDraftBlockBoundary(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := codahale.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, l := range []int{63, 64, 65, 127, 128, 129} {
+		plaintext := bytes.Repeat([]byte{0x42}, l)
+
+		expected := x.Seal(nil, nonce, plaintext, data)
+		if actual := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual)
+		}
+
+		actual, err := c.Open(nil, nonce, expected, data)
+		if err != nil {
+			t.Errorf("Failed to open %d bytes: %v", l, err)
+		} else if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open of %d bytes: expected %x, was %x", l, plaintext, actual)
+		}
+	}
+}
+
+func TestPad16Len(t *testing.T) {
+	for n := 0; n <= 64; n++ {
+		pad := Pad16Len(n)
+
+		if pad < 0 || pad >= 16 {
+			t.Errorf("Pad16Len(%d) = %d, out of range", n, pad)
+		}
+
+		if (n+pad)%16 != 0 {
+			t.Errorf("Pad16Len(%d) = %d, not a multiple of 16", n, pad)
+		}
+	}
+
+	for _, test := range []struct{ n, pad int }{
+		{0, 0}, {1, 15}, {15, 1}, {16, 0}, {17, 15}, {32, 0},
+	} {
+		if pad := Pad16Len(test.n); pad != test.pad {
+			t.Errorf("Pad16Len(%d) = %d, expected %d", test.n, pad, test.pad)
+		}
+	}
+}
+
+func TestCodahaleCompat(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	old, err := codahale.New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewCodahaleCompat(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, old.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	actual, err := c.Open(nil, nonce, old.Seal(nil, nonce, plaintext, data), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if actual, err = old.Open(nil, nonce, c.Seal(nil, nonce, plaintext, data), data); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+}
+
+// entryPointErrors calls every seal and open method of c with a zero nonce,
+// plaintext and data, or a ciphertext of the matching length, and returns
+// the error that each returned or panicked with, keyed by method name.
+func entryPointErrors(c cipher.AEAD, plaintext, data []byte) map[string]interface{} {
+	nonce := make([]byte, c.NonceSize())
+	ciphertext := make([]byte, len(plaintext)+c.Overhead())
+	tag := make([]byte, c.Overhead())
+	var polyKey [32]byte
+	tail := func(*[32]byte) {}
+
+	calls := map[string]func() error{
+		"Seal": func() error {
+			c.Seal(nil, nonce, plaintext, data)
+			return nil
+		},
+		"SealSafe": func() error {
+			_, err := c.(SafeSealAEAD).SealSafe(nil, nonce, plaintext, data)
+			return err
+		},
+		"SealBatch": func() error {
+			_, err := c.(BatchSealAEAD).SealBatch(nil, [][]byte{nonce}, [][]byte{plaintext}, [][]byte{data})
+			return err
+		},
+		"SealDetached": func() error {
+			c.(DetachedAEAD).SealDetached(nil, nonce, plaintext, data)
+			return nil
+		},
+		"SealWithDigest": func() error {
+			c.(DigestAEAD).SealWithDigest(nil, nonce, plaintext, data, sha256.New())
+			return nil
+		},
+		"SealWithPolyKey": func() error {
+			c.(PolyKeyAEAD).SealWithPolyKey(polyKey, nil, nonce, plaintext, data)
+			return nil
+		},
+		"SealWithBlock0Tail": func() error {
+			c.(Block0TailAEAD).SealWithBlock0Tail(nil, nonce, plaintext, data, tail)
+			return nil
+		},
+		"SealWithADs": func() error {
+			c.(MultiADAEAD).SealWithADs(nil, nonce, plaintext, data)
+			return nil
+		},
+		"SealReader": func() error {
+			return c.(ReaderAEAD).SealReader(ioutil.Discard, nonce, bytes.NewReader(plaintext), len(plaintext), data)
+		},
+		"Open": func() error {
+			_, err := c.Open(nil, nonce, ciphertext, data)
+			return err
+		},
+		"OpenDetached": func() error {
+			_, err := c.(DetachedAEAD).OpenDetached(nil, nonce, ciphertext[:len(plaintext)], tag, data)
+			return err
+		},
+		"OpenWithPolyKey": func() error {
+			_, err := c.(PolyKeyAEAD).OpenWithPolyKey(polyKey, nil, nonce, ciphertext, data)
+			return err
+		},
+		"OpenWithBlock0Tail": func() error {
+			_, err := c.(Block0TailAEAD).OpenWithBlock0Tail(nil, nonce, ciphertext, data, tail)
+			return err
+		},
+		"OpenWithADs": func() error {
+			_, err := c.(MultiADAEAD).OpenWithADs(nil, nonce, ciphertext, data)
+			return err
+		},
+		"OpenStrict": func() error {
+			_, err := c.(StrictOpenAEAD).OpenStrict(make([]byte, 0, len(plaintext)), nonce, ciphertext, data)
+			return err
+		},
+		"OpenAt": func() error {
+			_, err := c.(OpenAtAEAD).OpenAt(nil, nonce, ciphertext, data, 0)
+			return err
+		},
+		"Verify": func() error {
+			return c.(VerifyAEAD).Verify(nonce, ciphertext, data)
+		},
+	}
+
+	errs := make(map[string]interface{}, len(calls))
+	for name, call := range calls {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					errs[name] = r
+				}
+			}()
+
+			errs[name] = call()
+		}()
+	}
+
+	return errs
+}
+
+func TestRFCPlaintextTooLongEntryPoints(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	// Starting from the last block leaves room for only 64 bytes.
+	c, err := NewRFCWithCounter(key, 1<<32-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, err := range entryPointErrors(c, make([]byte, 65), nil) {
+		if err != ErrPlaintextTooLong {
+			t.Errorf("%s: expected plaintext too long error but was %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sync"
+)
+
+// ErrCounterRewind is returned by CounterChannel.Resync when asked to move
+// the counter backwards.
+var ErrCounterRewind = errors.New("counter may not move backwards")
+
+// CounterChannel seals and opens messages on one direction of a link where
+// both ends keep a synchronized message counter, so that the nonce need not
+// be transmitted. Each nonce is the 32-bit, little-endian prefix followed by
+// the 64-bit, little-endian counter, starting from zero.
+//
+// Every CounterChannel that seals under a key must use a different prefix.
+// Two channels sealing with the same key and prefix, such as both directions
+// of a link or a channel recreated after a restart, reuse nonces and so lose
+// all confidentiality and authenticity. Give each direction its own prefix,
+// and each new instance a new key or an unused prefix; the receiving end of
+// a direction uses the same prefix as its sender.
+//
+// The sending end calls only Seal and the receiving end only Open. If the
+// counters fall out of step, Open returns ErrAuthFailed until Resync is
+// called with the sender's counter. As Open only advances the counter on
+// success, the receiver can only fall behind the sender, never overtake it.
+//
+// A CounterChannel is safe for concurrent use.
+type CounterChannel struct {
+	aead cipher.AEAD
+
+	prefix uint32
+
+	mu      sync.Mutex
+	counter uint64
+}
+
+// NewCounterChannel returns a CounterChannel that uses c with the given nonce
+// prefix. c must use 12-byte RFC7539 nonces, otherwise ErrInvalidNonce is
+// returned. The prefix must not be shared with any other channel sealing
+// under the same key.
+func NewCounterChannel(c cipher.AEAD, prefix uint32) (*CounterChannel, error) {
+	if c.NonceSize() != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return &CounterChannel{aead: c, prefix: prefix}, nil
+}
+
+// Counter returns the counter value that the next call to Seal or Open will
+// use.
+func (ch *CounterChannel) Counter() uint64 {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	return ch.counter
+}
+
+// Resync advances the counter that the next call to Seal or Open will use.
+// It returns ErrCounterRewind, and leaves the counter unchanged, if counter is
+// less than the current counter: moving backwards would reuse nonces on the
+// sending end and allow replays on the receiving end.
+func (ch *CounterChannel) Resync(counter uint64) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if counter < ch.counter {
+		return ErrCounterRewind
+	}
+
+	ch.counter = counter
+	return nil
+}
+
+func (ch *CounterChannel) nonce() []byte {
+	nonce := make([]byte, RFCNonceSize)
+	binary.LittleEndian.PutUint32(nonce, ch.prefix)
+	binary.LittleEndian.PutUint64(nonce[4:], ch.counter)
+	return nonce
+}
+
+// Seal encrypts and authenticates plaintext with the next nonce, appending
+// the ciphertext and tag, but not the nonce, to dst. It returns
+// ErrNoncesExhausted if the counter would wrap.
+func (ch *CounterChannel) Seal(dst, plaintext, data []byte) ([]byte, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.counter == math.MaxUint64 {
+		return nil, ErrNoncesExhausted
+	}
+
+	out := ch.aead.Seal(dst, ch.nonce(), plaintext, data)
+	ch.counter++
+	return out, nil
+}
+
+// Open authenticates and decrypts ciphertext with the next nonce, appending
+// the plaintext to dst. The counter is only advanced if authentication
+// succeeds.
+func (ch *CounterChannel) Open(dst, ciphertext, data []byte) ([]byte, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if ch.counter == math.MaxUint64 {
+		return nil, ErrNoncesExhausted
+	}
+
+	out, err := ch.aead.Open(dst, ch.nonce(), ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ch.counter++
+	return out, nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tmthrgd/poly1305"
+)
+
+func TestCounterChannel(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, err := NewCounterChannel(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receiver, err := NewCounterChannel(c, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		plaintext := []byte{byte(i)}
+
+		ciphertext, err := sender.Seal(nil, plaintext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(ciphertext) != len(plaintext)+poly1305.TagSize {
+			t.Fatalf("Bad seal: expected %d bytes, was %d", len(plaintext)+poly1305.TagSize, len(ciphertext))
+		}
+
+		opened, err := receiver.Open(nil, ciphertext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Fatalf("Bad open: expected %x, was %x", plaintext, opened)
+		}
+	}
+
+	if receiver.Counter() != 3 {
+		t.Errorf("Expected counter to be 3 but was %d", receiver.Counter())
+	}
+}
+
+func TestCounterChannelOutOfSync(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, _ := NewCounterChannel(c, 1)
+	receiver, _ := NewCounterChannel(c, 1)
+
+	// The first message is lost.
+	sender.Seal(nil, []byte("lost"), nil)
+
+	ciphertext, err := sender.Seal(nil, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := receiver.Open(nil, ciphertext, nil); err != ErrAuthFailed {
+		t.Fatalf("Expected auth failure error but was %v", err)
+	}
+
+	if receiver.Counter() != 0 {
+		t.Fatalf("Expected counter to be unchanged but was %d", receiver.Counter())
+	}
+
+	if err := receiver.Resync(1); err != nil {
+		t.Fatal(err)
+	}
+
+	opened, err := receiver.Open(nil, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(opened, []byte("hello")) {
+		t.Fatalf("Bad open: expected %x, was %x", []byte("hello"), opened)
+	}
+}
+
+func TestCounterChannelResyncRewind(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, _ := NewCounterChannel(c, 1)
+
+	first, err := sender.Seal(nil, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sender.Resync(0); err != ErrCounterRewind {
+		t.Fatalf("Expected counter rewind error but was %v", err)
+	}
+
+	if sender.Counter() != 1 {
+		t.Fatalf("Expected counter to be unchanged but was %d", sender.Counter())
+	}
+
+	second, err := sender.Seal(nil, []byte("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(first, second) {
+		t.Error("Expected a rewound sender not to reuse a nonce")
+	}
+
+	if err := sender.Resync(sender.Counter()); err != nil {
+		t.Errorf("Expected resync to the current counter to succeed but was %v", err)
+	}
+}
+
+func TestCounterChannelInvalidNonce(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCounterChannel(c, 1); err != ErrInvalidNonce {
+		t.Fatalf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestCounterChannelPrefix(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := NewCounterChannel(c, 1)
+	b, _ := NewCounterChannel(c, 2)
+
+	plaintext := []byte("hello")
+
+	fromA, err := a.Seal(nil, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromB, err := b.Seal(nil, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(fromA, fromB) {
+		t.Error("Expected channels with different prefixes not to share nonces")
+	}
+
+	nonce := []byte{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	if expected := c.Seal(nil, nonce, plaintext, nil); !bytes.Equal(expected, fromB) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, fromB)
+	}
+
+	receiver, _ := NewCounterChannel(c, 1)
+	if _, err := receiver.Open(nil, fromB, nil); err != ErrAuthFailed {
+		t.Errorf("Expected auth failure error but was %v", err)
+	}
+}
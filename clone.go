@@ -0,0 +1,48 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// CloneableAEAD is implemented by the AEADs returned from NewRFC, NewDraft,
+// NewX, NewRFCCommitting, NewRFCDualTag, NewRFCGuarded and NewRFCTruncatedTag.
+// It allows an AEAD to be copied so that each owner may, for instance, Destroy
+// its copy independently.
+type CloneableAEAD interface {
+	cipher.AEAD
+
+	// Clone returns an independent copy of the AEAD with the same key and
+	// configuration. Destroying the copy doesn't affect the original, or
+	// vice versa. The AEADs returned from NewRFCGuarded are the exception
+	// in that a clone shares the record of used nonces with the original,
+	// so that reuse of a nonce across the two is still detected.
+	Clone() cipher.AEAD
+}
+
+func (k *chacha20Key) Clone() cipher.AEAD {
+	clone := *k
+	return &clone
+}
+
+func (k *xchacha20Key) Clone() cipher.AEAD {
+	clone := *k
+	return &clone
+}
+
+func (k *dualTagKey) Clone() cipher.AEAD {
+	return &dualTagKey{k: k.k}
+}
+
+func (k *committingKey) Clone() cipher.AEAD {
+	return &committingKey{k: k.k}
+}
+
+func (k *guardedKey) Clone() cipher.AEAD {
+	return &guardedKey{k: k.k, guard: k.guard}
+}
+
+func (k *truncatedTagKey) Clone() cipher.AEAD {
+	return &truncatedTagKey{k: k.k, tagLen: k.tagLen}
+}
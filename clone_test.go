@@ -0,0 +1,108 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testClone(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clone := c.(CloneableAEAD).Clone()
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	expected := c.Seal(nil, nonce, plaintext, data)
+
+	nonce[0] = 1
+	actual := clone.Seal(nil, nonce, plaintext, data)
+
+	if opened, err := c.Open(nil, nonce, actual, data); err != nil || !bytes.Equal(plaintext, opened) {
+		t.Errorf("Bad open of clone's seal: expected %x, was %x (%v)", plaintext, opened, err)
+	}
+
+	nonce[0] = 0
+	if opened, err := clone.Open(nil, nonce, expected, data); err != nil || !bytes.Equal(plaintext, opened) {
+		t.Errorf("Bad open by clone: expected %x, was %x (%v)", plaintext, opened, err)
+	}
+
+	clone.(DestroyableAEAD).Destroy()
+
+	if _, err := clone.Open(nil, nonce, expected, data); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	if opened, err := c.Open(nil, nonce, expected, data); err != nil || !bytes.Equal(plaintext, opened) {
+		t.Errorf("Bad open after destroying clone: expected %x, was %x (%v)", plaintext, opened, err)
+	}
+
+	nonce[0] = 2
+	c.Seal(nil, nonce, plaintext, data)
+}
+
+func TestRFCClone(t *testing.T) {
+	testClone(t, NewRFC)
+}
+
+func TestDraftClone(t *testing.T) {
+	testClone(t, NewDraft)
+}
+
+func TestXClone(t *testing.T) {
+	testClone(t, NewX)
+}
+
+func TestRFCWithCounterClone(t *testing.T) {
+	testClone(t, func(key []byte) (cipher.AEAD, error) {
+		return NewRFCWithCounter(key, 42)
+	})
+}
+
+func TestRFCDualTagClone(t *testing.T) {
+	testClone(t, NewRFCDualTag)
+}
+
+func TestRFCCommittingClone(t *testing.T) {
+	testClone(t, NewRFCCommitting)
+}
+
+func TestRFCGuardedClone(t *testing.T) {
+	testClone(t, NewRFCGuarded)
+
+	c, err := NewRFCGuarded(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	c.Seal(nil, nonce, nil, nil)
+
+	defer func() {
+		if r := recover(); r != ErrNonceReused {
+			t.Errorf("Expected nonce reused panic but was %v", r)
+		}
+	}()
+
+	c.(CloneableAEAD).Clone().Seal(nil, nonce, nil, nil)
+}
+
+func TestRFCTruncatedTagClone(t *testing.T) {
+	testClone(t, func(key []byte) (cipher.AEAD, error) {
+		return NewRFCTruncatedTag(key, 12)
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// commitmentSize is the size of the key commitment prepended by
+// NewRFCCommitting.
+const commitmentSize = 32
+
+// commitmentLabel is the kdf label used to derive key commitments.
+const commitmentLabel = "chacha20poly1305 key commitment"
+
+// NewRFCCommitting creates a new AEAD instance using the given key. The key
+// must be exactly 256 bits long. The returned cipher is a NON-STANDARD
+// variant of the RFC7539 AEAD construct that commits to the key, so that a
+// ciphertext can only be opened by the key that sealed it. This defends
+// against partitioning oracle attacks, which exploit the ability to craft a
+// ciphertext that opens under many keys.
+//
+// The output of Seal is a 32-byte commitment followed by the standard RFC7539
+// ciphertext and tag, so Overhead is 48 bytes. The commitment is
+// HMAC-SHA256(key, "chacha20poly1305 key commitment" || 0x00 || nonce). It is
+// derived separately from the ChaCha20 keystream, so it is unrelated to the
+// last 32 bytes of the counter-0 block exposed by Block0TailAEAD and used by
+// NewRFCDualTag. Open checks the commitment, in constant time, before the tag
+// and returns ErrAuthFailed if either mismatches.
+//
+// As the commitment is shifted ahead of the ciphertext, dst must not overlap
+// plaintext in Seal, nor ciphertext in Open.
+func NewRFCCommitting(key []byte) (cipher.AEAD, error) {
+	return NewWithOptions(key, WithKeyCommitment())
+}
+
+// committingKey doesn't embed chacha20Key so as not to inherit its extension
+// methods, which don't produce or check the commitment.
+type committingKey struct {
+	k chacha20Key
+}
+
+func (k *committingKey) NonceSize() int {
+	return k.k.NonceSize()
+}
+
+func (*committingKey) Overhead() int {
+	return commitmentSize + poly1305.TagSize
+}
+
+func (k *committingKey) Seal(dst, nonce, plaintext, data []byte) []byte {
+	if err := k.k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	ret, out := sliceForAppend(dst, commitmentSize+len(plaintext)+poly1305.TagSize)
+	if anyOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	copy(out, k.commitment(nonce))
+
+	c := k.k.stream(nonce)
+
+	pk := k.k.keyBlock(c)
+	defer k.k.putKeyBlock(pk)
+
+	k.k.seal(c, pk[:32], out[:commitmentSize], plaintext, data, nil)
+	return ret
+}
+
+func (k *committingKey) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.k.checkOpen(nonce, len(ciphertext), k.Overhead(), uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	commitment := ciphertext[:commitmentSize]
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[commitmentSize : len(ciphertext)-poly1305.TagSize]
+
+	if subtle.ConstantTimeCompare(k.commitment(nonce), commitment) != 1 {
+		return nil, ErrAuthFailed
+	}
+
+	c := k.k.stream(nonce)
+
+	pk := k.k.keyBlock(c)
+	defer k.k.putKeyBlock(pk)
+
+	return k.k.open(c, pk[:32], dst, ciphertext, tag, data)
+}
+
+// commitment returns the key commitment for nonce.
+func (k *committingKey) commitment(nonce []byte) []byte {
+	return kdf(k.k.key[:], commitmentLabel, nonce)
+}
+
+func (k *committingKey) Destroy() {
+	k.k.Destroy()
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestRFCCommitting(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		c, err := NewRFCCommitting(vector.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.Overhead() != 48 {
+			t.Errorf("Expected overhead of 48 but was %d", c.Overhead())
+		}
+
+		h := hmac.New(sha256.New, vector.key)
+		h.Write([]byte("chacha20poly1305 key commitment\x00"))
+		h.Write(vector.nonce)
+		commitment := h.Sum(nil)
+
+		ciphertext := c.Seal(nil, vector.nonce, vector.plaintext, vector.data)
+
+		// The output is the commitment followed by the RFC7539 output.
+		if expected := append(commitment, vector.ciphertext...); !bytes.Equal(expected, ciphertext) {
+			t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+		}
+
+		// The commitment must not reuse the tail of the counter-0 block,
+		// which NewRFCDualTag and Block0TailAEAD use.
+		block, err := ChaCha20Block0(vector.key, vector.nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(block[32:], ciphertext[:commitmentSize]) {
+			t.Error("Expected commitment to differ from the tail of the counter-0 block")
+		}
+
+		actual, err := c.Open(nil, vector.nonce, ciphertext, vector.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(vector.plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+		}
+
+		for _, i := range []int{0, commitmentSize - 1, commitmentSize, len(ciphertext) - 1} {
+			tampered := append([]byte(nil), ciphertext...)
+			tampered[i] ^= 1
+
+			if _, err := c.Open(nil, vector.nonce, tampered, vector.data); err != ErrAuthFailed {
+				t.Errorf("Expected message authentication failed error for byte %d but was %v", i, err)
+			}
+		}
+
+		if _, err := c.Open(nil, vector.nonce, ciphertext[:47], vector.data); err != ErrCiphertextTooShort {
+			t.Errorf("Expected ciphertext too short error but was %v", err)
+		}
+	}
+
+	if _, err := NewRFCCommitting(make([]byte, 31)); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestRFCCommittingWrongKey(t *testing.T) {
+	keyA := make([]byte, KeySize)
+	keyB := bytes.Repeat([]byte{1}, KeySize)
+
+	a, err := NewRFCCommitting(keyA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewRFCCommitting(keyB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, a.NonceSize())
+	ciphertext := a.Seal(nil, nonce, []byte("yay for me"), nil)
+
+	if _, err := a.Open(nil, nonce, ciphertext, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := b.Open(nil, nonce, ciphertext, nil); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
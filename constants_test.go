@@ -0,0 +1,62 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"testing"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/poly1305"
+	xcrypto "golang.org/x/crypto/chacha20poly1305"
+)
+
+// These fail to compile if the constants differ from those of the packages
+// they mirror, as one of each pair of array lengths would be negative.
+var (
+	_ [KeySize - chacha20.KeySize]struct{}
+	_ [chacha20.KeySize - KeySize]struct{}
+
+	_ [RFCNonceSize - chacha20.RFCNonceSize]struct{}
+	_ [chacha20.RFCNonceSize - RFCNonceSize]struct{}
+
+	_ [DraftNonceSize - chacha20.DraftNonceSize]struct{}
+	_ [chacha20.DraftNonceSize - DraftNonceSize]struct{}
+
+	_ [XNonceSize - xcrypto.NonceSizeX]struct{}
+	_ [xcrypto.NonceSizeX - XNonceSize]struct{}
+
+	_ [TagSize - poly1305.TagSize]struct{}
+	_ [poly1305.TagSize - TagSize]struct{}
+)
+
+func TestConstants(t *testing.T) {
+	for _, test := range []struct {
+		name             string
+		new              func(key []byte) (cipher.AEAD, error)
+		nonceSize, value int
+	}{
+		{"RFC", NewRFC, RFCNonceSize, 12},
+		{"Draft", NewDraft, DraftNonceSize, 8},
+		{"X", NewX, XNonceSize, 24},
+	} {
+		c, err := test.new(make([]byte, KeySize))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.nonceSize != test.value || c.NonceSize() != test.nonceSize {
+			t.Errorf("%s: expected nonce size of %d but constant was %d and NonceSize was %d", test.name, test.value, test.nonceSize, c.NonceSize())
+		}
+
+		if c.Overhead() != TagSize {
+			t.Errorf("%s: expected overhead of %d but was %d", test.name, TagSize, c.Overhead())
+		}
+	}
+
+	if TagSize != 16 {
+		t.Errorf("Expected tag size of 16 but was %d", TagSize)
+	}
+}
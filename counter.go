@@ -0,0 +1,64 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+// ErrInvalidCounter is returned by NewRFCWithCounter when the initial block
+// counter is zero.
+var ErrInvalidCounter = errors.New("invalid initial block counter: must not be zero")
+
+// NewRFCWithCounter behaves like NewRFC but the returned cipher encrypts
+// starting from the given ChaCha20 block counter, rather than one. The
+// one-time Poly1305 key is still derived from the counter-0 block, so counter
+// must not be zero, otherwise ErrInvalidCounter is returned. A counter of one
+// is identical to NewRFC.
+//
+// The maximum plaintext length is reduced accordingly, so that the counter
+// never wraps.
+func NewRFCWithCounter(key []byte, counter uint32) (cipher.AEAD, error) {
+	return NewWithOptions(key, WithInitialCounter(counter))
+}
+
+// counterStream is a ChaCha20 cipher that produces the counter-0 block
+// followed by the keystream from counter onwards. Unlike
+// github.com/tmthrgd/chacha20, golang.org/x/crypto/chacha20 is able to seek.
+type counterStream struct {
+	c       *xchacha20.Cipher
+	counter uint32
+	pos     int // bytes of the counter-0 block consumed
+}
+
+func newCounterStream(key, nonce []byte, counter uint32) *counterStream {
+	c, err := xchacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	return &counterStream{c: c, counter: counter}
+}
+
+func (s *counterStream) XORKeyStream(dst, src []byte) {
+	if s.pos < 64 {
+		n := 64 - s.pos
+		if n > len(src) {
+			n = len(src)
+		}
+
+		s.c.XORKeyStream(dst[:n], src[:n])
+		dst, src = dst[n:], src[n:]
+
+		if s.pos += n; s.pos == 64 {
+			s.c.SetCounter(s.counter)
+		}
+	}
+
+	s.c.XORKeyStream(dst, src)
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRFCWithCounter(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		c, err := NewRFCWithCounter(vector.key, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if actual := c.Seal(nil, vector.nonce, vector.plaintext, vector.data); !bytes.Equal(vector.ciphertext, actual) {
+			t.Errorf("Bad seal: expected %x, was %x", vector.ciphertext, actual)
+		}
+	}
+
+	key := make([]byte, KeySize)
+	nonce := make([]byte, 12)
+	data := []byte("whoah yeah")
+
+	c, err := NewRFCWithCounter(key, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rfc, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Starting from counter 3 skips the first two blocks of the default
+	// keystream, but keeps the same Poly1305 key.
+	for _, l := range []int{0, 1, smallSealLen, 200} {
+		plaintext := bytes.Repeat([]byte{0x42}, l)
+
+		keystream := rfc.Seal(nil, nonce, make([]byte, 128+l), nil)[128 : 128+l]
+		expected := make([]byte, l)
+		for i := range expected {
+			expected[i] = plaintext[i] ^ keystream[i]
+		}
+
+		actual := c.Seal(nil, nonce, plaintext, data)
+		if !bytes.Equal(expected, actual[:l]) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual[:l])
+		}
+
+		block, err := ChaCha20Block0(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var tag [16]byte
+		c.(*chacha20Key).auth(block[:32], tag[:], actual[:l], data)
+
+		if !bytes.Equal(tag[:], actual[l:]) {
+			t.Errorf("Bad tag of %d bytes: expected %x, was %x", l, tag, actual[l:])
+		}
+
+		opened, err := c.Open(nil, nonce, actual, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Errorf("Bad open of %d bytes: expected %x, was %x", l, plaintext, opened)
+		}
+	}
+
+	if _, err := NewRFCWithCounter(key, 0); err != ErrInvalidCounter {
+		t.Errorf("Expected invalid counter error but was %v", err)
+	}
+
+	if _, err := NewRFCWithCounter(key[:31], 1); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// CounterNonceAEAD is implemented by the AEADs returned from NewRFC and
+// NewDraft. It builds each nonce from a message counter, so that only the
+// counter, or nothing at all if it is synchronized, need be conveyed.
+//
+// The nonce for counter is the 8-byte, little-endian encoding of counter,
+// followed by zero bytes up to NonceSize. This matches the nonces returned by
+// NewRFCNonceSequence and NewDraftNonceSequence, and distinct counters always
+// give distinct nonces. The nonce is not keyed, so each counter value must
+// only ever be used once per key, across every sender using that key.
+type CounterNonceAEAD interface {
+	cipher.AEAD
+
+	// CounterNonce returns the nonce for counter.
+	CounterNonce(counter uint64) []byte
+
+	// SealCounter behaves like Seal with the nonce for counter.
+	SealCounter(dst []byte, counter uint64, plaintext, data []byte) []byte
+
+	// OpenCounter behaves like Open with the nonce for counter.
+	OpenCounter(dst []byte, counter uint64, ciphertext, data []byte) ([]byte, error)
+}
+
+func (k *chacha20Key) CounterNonce(counter uint64) []byte {
+	nonce := make([]byte, k.NonceSize())
+	binary.LittleEndian.PutUint64(nonce, counter)
+	return nonce
+}
+
+func (k *chacha20Key) SealCounter(dst []byte, counter uint64, plaintext, data []byte) []byte {
+	return k.Seal(dst, k.CounterNonce(counter), plaintext, data)
+}
+
+func (k *chacha20Key) OpenCounter(dst []byte, counter uint64, ciphertext, data []byte) ([]byte, error) {
+	return k.Open(dst, k.CounterNonce(counter), ciphertext, data)
+}
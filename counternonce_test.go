@@ -0,0 +1,80 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testCounterNonce(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cn := c.(CounterNonceAEAD)
+
+	seen := make(map[string]uint64)
+	for counter := uint64(0); counter < 1000; counter++ {
+		nonce := cn.CounterNonce(counter)
+		if len(nonce) != c.NonceSize() {
+			t.Fatalf("Expected nonce of %d bytes but was %d", c.NonceSize(), len(nonce))
+		}
+
+		if prev, ok := seen[string(nonce)]; ok {
+			t.Fatalf("Counters %d and %d derived the same nonce %x", prev, counter, nonce)
+		}
+
+		seen[string(nonce)] = counter
+
+		if again := cn.CounterNonce(counter); !bytes.Equal(nonce, again) {
+			t.Fatalf("Expected nonce for counter %d to be reproducible, was %x then %x", counter, nonce, again)
+		}
+	}
+
+	expected := make([]byte, c.NonceSize())
+	expected[0] = 42
+
+	if actual := cn.CounterNonce(42); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad counter nonce: expected %x, was %x", expected, actual)
+	}
+
+	if bytes.Equal(cn.CounterNonce(0), cn.CounterNonce(1<<63)) {
+		t.Error("Expected counters 0 and 1<<63 to give different nonces")
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertext := cn.SealCounter(nil, 42, plaintext, data)
+	if expected := c.Seal(nil, cn.CounterNonce(42), plaintext, data); !bytes.Equal(expected, ciphertext) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+	}
+
+	actual, err := cn.OpenCounter(nil, 42, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if _, err := cn.OpenCounter(nil, 43, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestRFCCounterNonce(t *testing.T) {
+	testCounterNonce(t, NewRFC)
+}
+
+func TestDraftCounterNonce(t *testing.T) {
+	testCounterNonce(t, NewDraft)
+}
@@ -0,0 +1,31 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build chacha20poly1305debug
+// +build chacha20poly1305debug
+
+package chacha20poly1305
+
+import (
+	"log"
+	"os"
+)
+
+// debugLogger receives warnings about suspicious usage when built with the
+// chacha20poly1305debug tag.
+var debugLogger = log.New(os.Stderr, "chacha20poly1305: ", log.LstdFlags|log.Lshortfile)
+
+// checkDst warns when Seal is passed a dst that already holds at least a
+// whole sealed message's worth of data. Seal appends to dst, so repeatedly
+// sealing into the same buffer without resetting it to buf[:0] accumulates
+// messages rather than overwriting them.
+//
+// calldepth is the number of frames between the caller of checkDst and the
+// user's call into this package, so that the warning reports the user's
+// file and line; it is 1 if the user called checkDst's caller directly.
+func checkDst(calldepth int, dst, plaintext []byte, overhead int) {
+	if len(dst) >= len(plaintext)+overhead {
+		debugLogger.Output(calldepth+2, "Seal called with a non-empty dst, the output will be appended; reset the buffer with buf[:0] to overwrite it")
+	}
+}
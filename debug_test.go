@@ -0,0 +1,70 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build chacha20poly1305debug
+// +build chacha20poly1305debug
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"strings"
+	"testing"
+)
+
+func testCheckDstCaller(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	c, err := newChaCha20Poly1305(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := debugLogger.Writer()
+	debugLogger.SetOutput(&buf)
+	defer debugLogger.SetOutput(w)
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	dst := make([]byte, len(plaintext)+c.Overhead())
+
+	calls := map[string]func(){
+		"Seal": func() {
+			c.Seal(dst, nonce, plaintext, nil)
+		},
+	}
+
+	if s, ok := c.(SafeSealAEAD); ok {
+		calls["SealSafe"] = func() {
+			s.SealSafe(dst, nonce, plaintext, nil)
+		}
+	}
+
+	if b, ok := c.(BatchSealAEAD); ok {
+		calls["SealBatch"] = func() {
+			b.SealBatch([][]byte{dst}, [][]byte{nonce}, [][]byte{plaintext}, nil)
+		}
+	}
+
+	for name, call := range calls {
+		buf.Reset()
+		call()
+
+		if !strings.Contains(buf.String(), "debug_test.go:") {
+			t.Errorf("%s: expected warning to report the caller but was %q", name, buf.String())
+		}
+	}
+}
+
+func TestRFCCheckDstCaller(t *testing.T) {
+	testCheckDstCaller(t, NewRFC)
+}
+
+func TestXCheckDstCaller(t *testing.T) {
+	testCheckDstCaller(t, NewX)
+}
+
+func TestRFCGuardedCheckDstCaller(t *testing.T) {
+	testCheckDstCaller(t, NewRFCGuarded)
+}
@@ -0,0 +1,34 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// DeclaredLengthData returns the additional data used by SealDeclaredLength
+// and OpenDeclaredLength: the declared length as an 8-byte, little-endian
+// value followed by data.
+func DeclaredLengthData(declared uint64, data []byte) []byte {
+	ad := make([]byte, 8+len(data))
+	binary.LittleEndian.PutUint64(ad, declared)
+	copy(ad[8:], data)
+	return ad
+}
+
+// SealDeclaredLength behaves like c.Seal but binds an application level
+// declared length into the additional data. The ciphertext will only open
+// with OpenDeclaredLength given the same declared length.
+func SealDeclaredLength(c cipher.AEAD, dst, nonce, plaintext, data []byte, declared uint64) []byte {
+	return c.Seal(dst, nonce, plaintext, DeclaredLengthData(declared, data))
+}
+
+// OpenDeclaredLength behaves like c.Open but requires the declared length to
+// match the one given to SealDeclaredLength, otherwise ErrAuthFailed is
+// returned.
+func OpenDeclaredLength(c cipher.AEAD, dst, nonce, ciphertext, data []byte, declared uint64) ([]byte, error) {
+	return c.Open(dst, nonce, ciphertext, DeclaredLengthData(declared, data))
+}
@@ -0,0 +1,50 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeclaredLength(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertext := SealDeclaredLength(c, nil, nonce, plaintext, data, uint64(len(plaintext)))
+
+	if expected := c.Seal(nil, nonce, plaintext, DeclaredLengthData(uint64(len(plaintext)), data)); !bytes.Equal(expected, ciphertext) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+	}
+
+	actual, err := OpenDeclaredLength(c, nil, nonce, ciphertext, data, uint64(len(plaintext)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if _, err = OpenDeclaredLength(c, nil, nonce, ciphertext, data, uint64(len(plaintext))+1); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = c.Open(nil, nonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if expected := mustHexDecode("0a00000000000000" + "77686f61682079656168"); !bytes.Equal(expected, DeclaredLengthData(10, data)) {
+		t.Errorf("Bad declared length data: expected %x, was %x", expected, DeclaredLengthData(10, data))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+// derivedNonceLabel is the HChaCha20 nonce used to derive the nonce
+// derivation subkey.
+var derivedNonceLabel = []byte("nonce derivation")
+
+// DerivedNonceAEAD is implemented by the AEADs returned from NewRFC and
+// NewDraft. It derives each nonce deterministically from the message rather
+// than requiring the caller to manage nonces.
+//
+// The nonce is the truncated HMAC-SHA256, keyed with HChaCha20(key, "nonce
+// derivation"), of the 8-byte, little-endian length of the additional data,
+// the additional data and the plaintext. Poly1305 is not used as it is only
+// secure as a one-time MAC.
+//
+// Identical (plaintext, data) pairs produce identical output, so an observer
+// can tell when a message is repeated. Distinct messages only receive distinct
+// nonces with overwhelming probability; for the 8-byte draft nonce a collision
+// is expected after roughly 2^32 messages.
+type DerivedNonceAEAD interface {
+	cipher.AEAD
+
+	// SealDerivedNonce derives a nonce from plaintext and data, seals
+	// plaintext with it and returns the nonce followed by the ciphertext.
+	SealDerivedNonce(plaintext, data []byte) []byte
+
+	// OpenDerivedNonce opens a ciphertext produced by SealDerivedNonce and
+	// verifies that its nonce was correctly derived.
+	OpenDerivedNonce(ciphertext, data []byte) ([]byte, error)
+}
+
+func (k *chacha20Key) deriveNonce(plaintext, data []byte) []byte {
+	subkey, err := xchacha20.HChaCha20(k.key[:], derivedNonceLabel)
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	defer wipe(subkey)
+
+	var l [8]byte
+	binary.LittleEndian.PutUint64(l[:], uint64(len(data)))
+
+	h := hmac.New(sha256.New, subkey)
+	h.Write(l[:])
+	h.Write(data)
+	h.Write(plaintext)
+	return h.Sum(nil)[:k.NonceSize()]
+}
+
+func (k *chacha20Key) SealDerivedNonce(plaintext, data []byte) []byte {
+	nonce := k.deriveNonce(plaintext, data)
+
+	out := make([]byte, len(nonce), len(nonce)+len(plaintext)+k.Overhead())
+	copy(out, nonce)
+	return k.Seal(out, nonce, plaintext, data)
+}
+
+func (k *chacha20Key) OpenDerivedNonce(ciphertext, data []byte) ([]byte, error) {
+	if len(ciphertext) < k.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := ciphertext[:k.NonceSize()], ciphertext[k.NonceSize():]
+
+	out, err := k.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(nonce, k.deriveNonce(out, data)) != 1 {
+		wipe(out)
+		return nil, ErrAuthFailed
+	}
+
+	return out, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+func testDerivedNonce(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := c.(DerivedNonceAEAD)
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertext := dc.SealDerivedNonce(plaintext, data)
+
+	subkey, err := xchacha20.HChaCha20(key, []byte("nonce derivation"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hmac.New(sha256.New, subkey)
+	h.Write([]byte{byte(len(data)), 0, 0, 0, 0, 0, 0, 0})
+	h.Write(data)
+	h.Write(plaintext)
+
+	if expected := h.Sum(nil)[:c.NonceSize()]; !bytes.Equal(expected, ciphertext[:c.NonceSize()]) {
+		t.Errorf("Bad derived nonce: expected %x, was %x", expected, ciphertext[:c.NonceSize()])
+	}
+
+	if again := dc.SealDerivedNonce(plaintext, data); !bytes.Equal(ciphertext, again) {
+		t.Errorf("Expected identical messages to seal identically: %x vs %x", ciphertext, again)
+	}
+
+	if other := dc.SealDerivedNonce(plaintext, data[1:]); bytes.Equal(ciphertext[:c.NonceSize()], other[:c.NonceSize()]) {
+		t.Error("Expected distinct messages to derive distinct nonces")
+	}
+
+	actual, err := dc.OpenDerivedNonce(ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	// A valid ciphertext under a nonce that wasn't derived from the
+	// message must be rejected.
+	nonce := make([]byte, c.NonceSize())
+	forged := append(nonce, c.Seal(nil, nonce, plaintext, data)...)
+
+	if _, err = dc.OpenDerivedNonce(forged, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = dc.OpenDerivedNonce(ciphertext[:c.NonceSize()-1], data); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+}
+
+func TestRFCDerivedNonce(t *testing.T) {
+	testDerivedNonce(t, NewRFC)
+}
+
+func TestDraftDerivedNonce(t *testing.T) {
+	testDerivedNonce(t, NewDraft)
+}
@@ -0,0 +1,34 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// DestroyableAEAD is implemented by the AEADs returned from NewRFC, NewDraft,
+// NewX, NewRFCCommitting, NewRFCDualTag, NewRFCGuarded and NewRFCTruncatedTag.
+// It allows the key to be wiped from memory once it is no longer needed.
+type DestroyableAEAD interface {
+	cipher.AEAD
+
+	// Destroy overwrites the key with zeros. Afterwards, Open and every
+	// other method that returns an error return ErrInvalidKey, and Seal and
+	// the other methods panic with ErrInvalidKey. Destroy must not be
+	// called concurrently with any other method.
+	Destroy()
+}
+
+func (k *chacha20Key) Destroy() {
+	wipe(k.key[:])
+	k.destroyed = true
+}
+
+func (k *xchacha20Key) Destroy() {
+	wipe(k.key[:])
+	k.destroyed = true
+}
+
+func (k *dualTagKey) Destroy() {
+	k.k.Destroy()
+}
@@ -0,0 +1,122 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"reflect"
+	"testing"
+)
+
+func testDestroy(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), field ...string) {
+	key := bytes.Repeat([]byte{0xff}, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	ciphertext := c.Seal(nil, nonce, []byte("yay for me"), nil)
+
+	c.(DestroyableAEAD).Destroy()
+
+	v := reflect.ValueOf(c).Elem()
+	for _, name := range field {
+		v = v.FieldByName(name)
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if v.Index(i).Uint() != 0 {
+			t.Fatalf("Expected key to be zeroed but byte %d was %#x", i, v.Index(i).Uint())
+		}
+	}
+
+	if _, err := c.Open(nil, nonce, ciphertext, nil); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	defer func() {
+		if r := recover(); r != ErrInvalidKey {
+			t.Errorf("Expected invalid key panic but was %v", r)
+		}
+	}()
+
+	c.Seal(nil, nonce, []byte("yay for me"), nil)
+}
+
+func TestRFCDestroy(t *testing.T) {
+	testDestroy(t, NewRFC, "key")
+}
+
+func TestDraftDestroy(t *testing.T) {
+	testDestroy(t, NewDraft, "key")
+}
+
+func TestXDestroy(t *testing.T) {
+	testDestroy(t, NewX, "key")
+}
+
+func TestRFCDualTagDestroy(t *testing.T) {
+	testDestroy(t, NewRFCDualTag, "k", "key")
+}
+
+func testDestroyEntryPoints(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	c, err := newChaCha20Poly1305(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.(DestroyableAEAD).Destroy()
+
+	for name, err := range entryPointErrors(c, []byte("yay for me"), nil) {
+		if err != ErrInvalidKey {
+			t.Errorf("%s: expected invalid key error but was %v", name, err)
+		}
+	}
+
+	// A ciphertext too short to hold a tag must not mask the destroyed key.
+	if _, err := c.Open(nil, make([]byte, c.NonceSize()), nil, nil); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestRFCDestroyEntryPoints(t *testing.T) {
+	testDestroyEntryPoints(t, NewRFC)
+}
+
+func TestDraftDestroyEntryPoints(t *testing.T) {
+	testDestroyEntryPoints(t, NewDraft)
+}
+
+func TestXDestroyInvalidNonce(t *testing.T) {
+	c, err := NewX(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.(DestroyableAEAD).Destroy()
+
+	nonce := make([]byte, RFCNonceSize)
+	ciphertext := make([]byte, TagSize)
+
+	for name, open := range map[string]func(){
+		"Open":       func() { c.Open(nil, nonce, ciphertext, nil) },
+		"Verify":     func() { c.(VerifyAEAD).Verify(nonce, ciphertext, nil) },
+		"OpenAt":     func() { c.(OpenAtAEAD).OpenAt(nil, nonce, ciphertext, nil, 0) },
+		"OpenStrict": func() { c.(StrictOpenAEAD).OpenStrict(nil, nonce, ciphertext, nil) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != ErrInvalidNonce {
+					t.Errorf("%s: expected invalid nonce panic but was %v", name, r)
+				}
+			}()
+
+			open()
+		}()
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// DetachedAEAD is implemented by the AEADs returned from NewRFC and NewDraft.
+// It allows the authentication tag to be stored separately from the
+// ciphertext.
+type DetachedAEAD interface {
+	cipher.AEAD
+
+	// SealDetached behaves like Seal but returns the tag separately rather
+	// than appending it to the ciphertext.
+	SealDetached(dst, nonce, plaintext, data []byte) (ciphertext []byte, tag [poly1305.TagSize]byte)
+
+	// OpenDetached behaves like Open but takes the tag separately from the
+	// ciphertext. The tag must be exactly poly1305.TagSize bytes long,
+	// otherwise ErrInvalidTagSize is returned; trailing bytes are never
+	// silently ignored.
+	OpenDetached(dst, nonce, ciphertext, tag, data []byte) ([]byte, error)
+}
+
+func (k *chacha20Key) SealDetached(dst, nonce, plaintext, data []byte) (ciphertext []byte, tag [poly1305.TagSize]byte) {
+	if err := k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	ret, out := sliceForAppend(dst, len(plaintext))
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	c.XORKeyStream(out, plaintext)
+
+	k.auth(pk[:32], tag[:], out, data)
+	return ret, tag
+}
+
+func (k *chacha20Key) OpenDetached(dst, nonce, ciphertext, tag, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), 0, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	if len(tag) != poly1305.TagSize {
+		return nil, ErrInvalidTagSize
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	return k.open(c, pk[:32], dst, ciphertext, tag, data)
+}
+
+// SealSplitTag behaves like c.SealDetached but appends the tag to tagDst,
+// growing it if needed, rather than returning an array. This suits frame
+// formats where the ciphertext grows a shared body buffer but the tag lives
+// in a separate header field.
+func SealSplitTag(c DetachedAEAD, ciphertextDst, tagDst, nonce, plaintext, data []byte) (ciphertext, tag []byte) {
+	ciphertext, t := c.SealDetached(ciphertextDst, nonce, plaintext, data)
+	return ciphertext, append(tagDst, t[:]...)
+}
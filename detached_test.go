@@ -0,0 +1,95 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+func testDetached(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), vector testVector) {
+	c, err := newChaCha20Poly1305(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := c.(DetachedAEAD)
+
+	ciphertext, tag := dc.SealDetached(nil, vector.nonce, vector.plaintext, vector.data)
+
+	if expected := vector.ciphertext[:len(vector.plaintext)]; !bytes.Equal(expected, ciphertext) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+	}
+
+	if expected := vector.ciphertext[len(vector.plaintext):]; !bytes.Equal(expected, tag[:]) {
+		t.Errorf("Bad tag: expected %x, was %x", expected, tag)
+	}
+
+	actual, err := dc.OpenDetached(nil, vector.nonce, ciphertext, tag[:], vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+	}
+
+	long := append(tag[:], 0)
+
+	if _, err = dc.OpenDetached(nil, vector.nonce, ciphertext, long, vector.data); err != ErrInvalidTagSize {
+		t.Errorf("Expected invalid tag size error but was %v", err)
+	}
+
+	if _, err = dc.OpenDetached(nil, vector.nonce, ciphertext, tag[:poly1305.TagSize-1], vector.data); err != ErrInvalidTagSize {
+		t.Errorf("Expected invalid tag size error but was %v", err)
+	}
+
+	tag[0] ^= 1
+
+	if _, err = dc.OpenDetached(nil, vector.nonce, ciphertext, tag[:], vector.data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestRFCDetached(t *testing.T) {
+	testDetached(t, NewRFC, rfcTestVectors[0])
+}
+
+func TestDraftDetached(t *testing.T) {
+	testDetached(t, NewDraft, draftTestVectors[0])
+}
+
+func TestSealSplitTag(t *testing.T) {
+	vector := rfcTestVectors[0]
+
+	c, err := NewRFC(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedCiphertext := vector.ciphertext[:len(vector.plaintext)]
+	expectedTag := vector.ciphertext[len(vector.plaintext):]
+
+	for _, tagDst := range [][]byte{nil, make([]byte, 0, poly1305.TagSize), []byte("header")} {
+		ciphertextDst := []byte("body")
+
+		ciphertext, tag := SealSplitTag(c.(DetachedAEAD), ciphertextDst, tagDst, vector.nonce, vector.plaintext, vector.data)
+
+		if expected := append([]byte("body"), expectedCiphertext...); !bytes.Equal(expected, ciphertext) {
+			t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+		}
+
+		if expected := append(append([]byte(nil), tagDst...), expectedTag...); !bytes.Equal(expected, tag) {
+			t.Errorf("Bad tag: expected %x, was %x", expected, tag)
+		}
+
+		if cap(tagDst) >= poly1305.TagSize && &tagDst[:1][0] != &tag[0] {
+			t.Error("SealSplitTag did not reuse the presized tagDst")
+		}
+	}
+}
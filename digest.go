@@ -0,0 +1,36 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"hash"
+)
+
+// DigestAEAD is implemented by the AEADs returned from NewRFC and NewDraft. It
+// computes a content digest of the sealed output in the same pass as
+// encryption.
+type DigestAEAD interface {
+	cipher.AEAD
+
+	// SealWithDigest behaves like Seal but also writes the output, the
+	// ciphertext followed by the tag, to h as it is produced. The
+	// resulting digest is identical to hashing the returned slice, less
+	// dst, afterwards.
+	SealWithDigest(dst, nonce, plaintext, data []byte, h hash.Hash) []byte
+}
+
+func (k *chacha20Key) SealWithDigest(dst, nonce, plaintext, data []byte, h hash.Hash) []byte {
+	if err := k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	c := k.stream(nonce)
+
+	pk := k.keyBlock(c)
+	defer k.putKeyBlock(pk)
+
+	return k.seal(c, pk[:32], dst, plaintext, data, h)
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func testSealWithDigest(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, l := range []int{0, 1, 64, sealChunkSize, sealChunkSize + 1, 3 * sealChunkSize} {
+		t.Run(fmt.Sprint(l), func(t *testing.T) {
+			plaintext := bytes.Repeat([]byte{0x42}, l)
+
+			h := sha256.New()
+			actual := c.(DigestAEAD).SealWithDigest([]byte("prefix"), nonce, plaintext, data, h)
+
+			expected := append([]byte("prefix"), c.Seal(nil, nonce, plaintext, data)...)
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+			}
+
+			if digest := sha256.Sum256(expected[len("prefix"):]); !bytes.Equal(digest[:], h.Sum(nil)) {
+				t.Errorf("Bad digest: expected %x, was %x", digest, h.Sum(nil))
+			}
+		})
+	}
+}
+
+func TestRFCSealWithDigest(t *testing.T) {
+	testSealWithDigest(t, NewRFC)
+}
+
+func TestDraftSealWithDigest(t *testing.T) {
+	testSealWithDigest(t, NewDraft)
+}
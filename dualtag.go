@@ -0,0 +1,103 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// NewRFCDualTag creates a new AEAD instance using the given key. The key must
+// be exactly 256 bits long. The returned cipher is a NON-STANDARD variant of
+// the RFC7539 AEAD construct that appends two tags, doubling the overhead to
+// 32 bytes.
+//
+// The first tag is the standard RFC7539 tag, computed with the first half of
+// the counter-0 keystream block. The second is computed over the same input
+// with the otherwise discarded second half of that block. Open requires both
+// tags to match. This guards against a fault in a single MAC computation; it
+// adds no security against a conventional attacker.
+func NewRFCDualTag(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := new(dualTagKey)
+	copy(k.k.key[:], key)
+	return k, nil
+}
+
+// dualTagKey doesn't embed chacha20Key so as not to inherit its extension
+// methods, which all assume a single tag.
+type dualTagKey struct {
+	k chacha20Key
+}
+
+func (k *dualTagKey) NonceSize() int {
+	return k.k.NonceSize()
+}
+
+func (*dualTagKey) Overhead() int {
+	return 2 * poly1305.TagSize
+}
+
+func (k *dualTagKey) Seal(dst, nonce, plaintext, data []byte) []byte {
+	if err := k.k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	c := k.k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	ret, out := sliceForAppend(dst, len(plaintext)+2*poly1305.TagSize)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	ciphertext, tags := out[:len(plaintext)], out[len(plaintext):]
+	c.XORKeyStream(ciphertext, plaintext)
+
+	k.k.auth(pk[:32], tags[:poly1305.TagSize], ciphertext, data)
+	k.k.auth(pk[32:], tags[poly1305.TagSize:], ciphertext, data)
+	return ret
+}
+
+func (k *dualTagKey) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.k.checkOpen(nonce, len(ciphertext), 2*poly1305.TagSize, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	tags := ciphertext[len(ciphertext)-2*poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-2*poly1305.TagSize]
+
+	c := k.k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	var expectedTags [2 * poly1305.TagSize]byte
+	k.k.auth(pk[:32], expectedTags[:poly1305.TagSize], ciphertext, data)
+	k.k.auth(pk[32:], expectedTags[poly1305.TagSize:], ciphertext, data)
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic(errInvalidOverlap)
+	}
+
+	if subtle.ConstantTimeCompare(expectedTags[:], tags) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	c.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRFCDualTag(t *testing.T) {
+	vector := rfcTestVectors[0]
+
+	c, err := NewRFCDualTag(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Overhead() != 32 {
+		t.Errorf("Expected overhead of 32 but was %d", c.Overhead())
+	}
+
+	ciphertext := c.Seal(nil, vector.nonce, vector.plaintext, vector.data)
+
+	if expected := vector.ciphertext; !bytes.Equal(expected, ciphertext[:len(expected)]) {
+		t.Errorf("Expected first tag to match RFC7539: expected %x, was %x", expected, ciphertext[:len(expected)])
+	}
+
+	actual, err := c.Open(nil, vector.nonce, ciphertext, vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+	}
+
+	for _, i := range []int{len(vector.plaintext), len(ciphertext) - 1} {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[i] ^= 1
+
+		if _, err = c.Open(nil, vector.nonce, tampered, vector.data); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error for byte %d but was %v", i, err)
+		}
+	}
+
+	if _, err = c.Open(nil, vector.nonce, ciphertext[:31], vector.data); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+
+	if _, err = NewRFCDualTag(vector.key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
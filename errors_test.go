@@ -0,0 +1,84 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build go1.13
+// +build go1.13
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func testErrorsIs(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	is := func(err, target error) {
+		t.Helper()
+
+		if wrapped := fmt.Errorf("wrapped: %w", err); !errors.Is(wrapped, target) {
+			t.Errorf("Expected %v to match %v through wrapping", wrapped, target)
+		}
+	}
+
+	_, err := newChaCha20Poly1305(make([]byte, KeySize-1))
+	is(err, ErrInvalidKey)
+
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	ciphertext := c.Seal(nil, nonce, []byte("yay for me"), nil)
+	ciphertext[0] ^= 1
+
+	_, err = c.Open(nil, nonce, ciphertext, nil)
+	is(err, ErrAuthFailed)
+
+	_, err = c.(SafeSealAEAD).SealSafe(nil, nonce[1:], nil, nil)
+	is(err, ErrInvalidNonce)
+
+	c.(DestroyableAEAD).Destroy()
+
+	_, err = c.Open(nil, nonce, ciphertext, nil)
+	is(err, ErrInvalidKey)
+}
+
+func TestRFCErrorsIs(t *testing.T) {
+	testErrorsIs(t, NewRFC)
+}
+
+func TestDraftErrorsIs(t *testing.T) {
+	testErrorsIs(t, NewDraft)
+}
+
+func TestXErrorsIs(t *testing.T) {
+	testErrorsIs(t, NewX)
+}
+
+func TestHelperErrorsIs(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		err    error
+		target error
+	}{
+		{"ChaCha20Block0 key", errOf(ChaCha20Block0(make([]byte, KeySize-1), make([]byte, 12))), ErrInvalidKey},
+		{"ChaCha20Block0 nonce", errOf(ChaCha20Block0(make([]byte, KeySize), make([]byte, 11))), ErrInvalidNonce},
+		{"HChaCha20 key", errOf(HChaCha20(make([]byte, KeySize-1), make([]byte, 16))), ErrInvalidKey},
+		{"HChaCha20 nonce", errOf(HChaCha20(make([]byte, KeySize), make([]byte, 15))), ErrInvalidNonce},
+		{"ComputeTag nonce", errOf(ComputeTag(make([]byte, KeySize), make([]byte, 11), nil, nil, false)), ErrInvalidNonce},
+	} {
+		if wrapped := fmt.Errorf("wrapped: %w", test.err); !errors.Is(wrapped, test.target) {
+			t.Errorf("%s: expected %v to match %v through wrapping", test.name, wrapped, test.target)
+		}
+	}
+}
+
+func errOf(_ interface{}, err error) error {
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// ErrExpired is returned by OpenWithExpiry when the message has expired.
+var ErrExpired = errors.New("message expired")
+
+const expiryLen = 8
+
+// SealWithExpiry behaves like c.Seal but embeds expiry in the message. The
+// expiry is stored, as an 8-byte, little-endian, signed count of Unix seconds,
+// at the start of the plaintext so it is both encrypted and authenticated.
+// Any fraction of a second in expiry is discarded, so the message expires up
+// to a second early. Every time.Time, including the zero Time, can be stored.
+func SealWithExpiry(c cipher.AEAD, dst, nonce, plaintext, data []byte, expiry time.Time) []byte {
+	buf := make([]byte, expiryLen+len(plaintext))
+	binary.LittleEndian.PutUint64(buf, uint64(expiry.Unix()))
+	copy(buf[expiryLen:], plaintext)
+
+	ret := c.Seal(dst, nonce, buf, data)
+	wipe(buf)
+	return ret
+}
+
+// OpenWithExpiry opens a message sealed by SealWithExpiry, returning
+// ErrExpired if the current time is after the embedded expiry.
+func OpenWithExpiry(c cipher.AEAD, dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	return OpenWithExpiryAt(c, time.Now(), dst, nonce, ciphertext, data)
+}
+
+// OpenWithExpiryAt behaves like OpenWithExpiry but checks the expiry against
+// now rather than the current time. The expiry is only checked once the
+// message has been authenticated.
+func OpenWithExpiryAt(c cipher.AEAD, now time.Time, dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	buf, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipe(buf)
+
+	if len(buf) < expiryLen {
+		return nil, ErrCiphertextTooShort
+	}
+
+	expiry := time.Unix(int64(binary.LittleEndian.Uint64(buf)), 0)
+	if now.After(expiry) {
+		return nil, ErrExpired
+	}
+
+	return append(dst, buf[expiryLen:]...), nil
+}
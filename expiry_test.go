@@ -0,0 +1,87 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestExpiry(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	expiry := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ciphertext := SealWithExpiry(c, nil, nonce, plaintext, data, expiry)
+
+	for _, now := range []time.Time{expiry.Add(-time.Hour), expiry} {
+		actual, err := OpenWithExpiryAt(c, now, nil, nonce, ciphertext, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+		}
+	}
+
+	if _, err = OpenWithExpiryAt(c, expiry.Add(time.Nanosecond), nil, nonce, ciphertext, data); err != ErrExpired {
+		t.Errorf("Expected message expired error but was %v", err)
+	}
+
+	if _, err = OpenWithExpiry(c, nil, nonce, ciphertext, data); err != ErrExpired {
+		t.Errorf("Expected message expired error but was %v", err)
+	}
+
+	ciphertext[0] ^= 1
+
+	if _, err = OpenWithExpiryAt(c, expiry.Add(time.Hour), nil, nonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestExpiryRange(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+
+	for _, expiry := range []time.Time{
+		{},
+		time.Date(1600, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2300, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC),
+	} {
+		ciphertext := SealWithExpiry(c, nil, nonce, plaintext, nil, expiry)
+
+		if _, err := OpenWithExpiryAt(c, expiry, nil, nonce, ciphertext, nil); err != nil {
+			t.Errorf("Expected expiry %v to be preserved but was %v", expiry, err)
+		}
+
+		if _, err := OpenWithExpiryAt(c, expiry.Add(time.Second), nil, nonce, ciphertext, nil); err != ErrExpired {
+			t.Errorf("Expected message expired error for %v but was %v", expiry, err)
+		}
+	}
+
+	// A message expiring in the far future must not appear already expired.
+	ciphertext := SealWithExpiry(c, nil, nonce, plaintext, nil, time.Date(2300, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if _, err := OpenWithExpiry(c, nil, nonce, ciphertext, nil); err != nil {
+		t.Errorf("Expected far future expiry not to have passed but was %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"sort"
+)
+
+// SealFields seals a set of named fields with c as a single message. The
+// fields are framed, as by FrameSealer, as a 4-byte, little-endian count
+// followed by each name and value as length-prefixed byte strings, in order
+// of name. The output is therefore the same for the same fields regardless
+// of map iteration order.
+func SealFields(c cipher.AEAD, nonce []byte, fields map[string][]byte, data []byte) []byte {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var fs FrameSealer
+	fs.Uint32(uint32(len(names)))
+
+	for _, name := range names {
+		fs.Bytes([]byte(name)).Bytes(fields[name])
+	}
+
+	return fs.Seal(c, nil, nonce, data)
+}
+
+// OpenFields opens a message sealed by SealFields and returns its fields.
+// The returned values alias a single buffer. ErrInvalidFrame is returned if
+// the message is authentic but isn't in the canonical form produced by
+// SealFields.
+func OpenFields(c cipher.AEAD, nonce, ciphertext, data []byte) (map[string][]byte, error) {
+	f, err := OpenFrame(c, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	n := f.Uint32()
+
+	// Each field takes at least 8 bytes, so n is bounded before it is
+	// used to size the map.
+	if uint64(n) > uint64(f.Remaining()/8) {
+		return nil, ErrInvalidFrame
+	}
+
+	fields := make(map[string][]byte, n)
+
+	var last string
+	for i := uint32(0); i < n; i++ {
+		name := string(f.Bytes())
+		value := f.Bytes()
+
+		if f.Err() != nil {
+			return nil, f.Err()
+		}
+
+		if i > 0 && name <= last {
+			return nil, ErrInvalidFrame
+		}
+
+		fields[name], last = value, name
+	}
+
+	if f.Err() != nil || f.Remaining() != 0 {
+		return nil, ErrInvalidFrame
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestFields(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	fields := map[string][]byte{
+		"username": []byte("coda"),
+		"password": []byte("yay for me"),
+		"":         []byte("empty name"),
+		"empty":    {},
+	}
+
+	ciphertext := SealFields(c, nonce, fields, data)
+
+	for i := 0; i < 10; i++ {
+		if again := SealFields(c, nonce, fields, data); !bytes.Equal(ciphertext, again) {
+			t.Fatalf("Expected reproducible output: %x != %x", ciphertext, again)
+		}
+	}
+
+	actual, err := OpenFields(c, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(actual) != len(fields) {
+		t.Errorf("Expected %d fields but was %d", len(fields), len(actual))
+	}
+
+	for name, expected := range fields {
+		if v, ok := actual[name]; !ok || !bytes.Equal(expected, v) {
+			t.Errorf("Bad field %q: expected %x, was %x", name, expected, v)
+		}
+	}
+
+	for i := range ciphertext {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[i] ^= 1
+
+		if _, err := OpenFields(c, nonce, tampered, data); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error for byte %d but was %v", i, err)
+		}
+	}
+
+	if _, err := OpenFields(c, nonce, ciphertext, data[1:]); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestFieldsEmpty(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+
+	actual, err := OpenFields(c, nonce, SealFields(c, nonce, nil, nil), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(map[string][]byte{}, actual) {
+		t.Errorf("Expected no fields but was %v", actual)
+	}
+}
+
+func TestFieldsNonCanonical(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+
+	for name, fs := range map[string]*FrameSealer{
+		"unsorted":  new(FrameSealer).Uint32(2).Bytes([]byte("b")).Bytes(nil).Bytes([]byte("a")).Bytes(nil),
+		"duplicate": new(FrameSealer).Uint32(2).Bytes([]byte("a")).Bytes(nil).Bytes([]byte("a")).Bytes(nil),
+		"trailing":  new(FrameSealer).Uint32(1).Bytes([]byte("a")).Bytes(nil).Uint8(0),
+		"truncated": new(FrameSealer).Uint32(1).Bytes([]byte("a")),
+		"count":     new(FrameSealer).Uint32(1 << 31),
+	} {
+		ciphertext := fs.Seal(c, nil, nonce, nil)
+
+		if _, err := OpenFields(c, nonce, ciphertext, nil); err != ErrInvalidFrame {
+			t.Errorf("%s: expected invalid frame error but was %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"reflect"
+	"unsafe"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// FootprintBytes reports the approximate memory cost, in bytes, of an AEAD
+// returned from NewRFC or NewDraft.
+//
+// instance is the size of the AEAD itself, which lives as long as the AEAD.
+//
+// perOperation is the transient scratch used by each Seal or Open call: the
+// ChaCha20 cipher state, the 64-byte counter-0 block, the Poly1305 key, the
+// Poly1305 MAC state and the tag. It does not include the MAC input buffer, which holds roughly
+// len(data)+len(ciphertext)+48 bytes and is drawn from a shared pool, nor the
+// output buffer.
+func FootprintBytes() (instance, perOperation int) {
+	instance = int(unsafe.Sizeof(chacha20Key{}))
+
+	perOperation = 64 + 32 + int(unsafe.Sizeof(poly1305.MAC{})) + poly1305.TagSize
+
+	var key [KeySize]byte
+	var nonce [RFCNonceSize]byte
+	if c, err := newChaCha20(key[:], nonce[:]); err == nil {
+		t := reflect.TypeOf(c)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		perOperation += int(t.Size())
+	}
+
+	return instance, perOperation
+}
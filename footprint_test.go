@@ -0,0 +1,24 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"testing"
+	"unsafe"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+func TestFootprintBytes(t *testing.T) {
+	instance, perOperation := FootprintBytes()
+
+	if instance < KeySize {
+		t.Errorf("Expected instance footprint of at least %d bytes but was %d", KeySize, instance)
+	}
+
+	if least := 64 + 32 + int(unsafe.Sizeof(poly1305.MAC{})) + 16; perOperation < least {
+		t.Errorf("Expected per-operation footprint of at least %d bytes but was %d", least, perOperation)
+	}
+}
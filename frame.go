@@ -0,0 +1,163 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidFrame is returned by FrameOpener when a field extends beyond the
+// end of the frame.
+var ErrInvalidFrame = errors.New("invalid frame")
+
+// FrameSealer assembles a sequence of fixed-size integers and length-prefixed
+// byte strings into a frame and seals it. Integers are encoded little-endian
+// and byte strings are prefixed with their length as a 4-byte, little-endian
+// value.
+//
+// The zero value is an empty frame ready to use.
+type FrameSealer struct {
+	buf []byte
+}
+
+// Uint8 appends v to the frame.
+func (f *FrameSealer) Uint8(v uint8) *FrameSealer {
+	f.buf = append(f.buf, v)
+	return f
+}
+
+// Uint16 appends v to the frame.
+func (f *FrameSealer) Uint16(v uint16) *FrameSealer {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	f.buf = append(f.buf, b[:]...)
+	return f
+}
+
+// Uint32 appends v to the frame.
+func (f *FrameSealer) Uint32(v uint32) *FrameSealer {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	f.buf = append(f.buf, b[:]...)
+	return f
+}
+
+// Uint64 appends v to the frame.
+func (f *FrameSealer) Uint64(v uint64) *FrameSealer {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	f.buf = append(f.buf, b[:]...)
+	return f
+}
+
+// Bytes appends v, prefixed with its length, to the frame.
+func (f *FrameSealer) Bytes(v []byte) *FrameSealer {
+	f.Uint32(uint32(len(v)))
+	f.buf = append(f.buf, v...)
+	return f
+}
+
+// Seal seals the assembled frame with c and appends the result to dst. The
+// frame's plaintext is zeroed and the FrameSealer is reset.
+func (f *FrameSealer) Seal(c cipher.AEAD, dst, nonce, data []byte) []byte {
+	ret := c.Seal(dst, nonce, f.buf, data)
+
+	wipe(f.buf)
+	f.buf = f.buf[:0]
+	return ret
+}
+
+// FrameOpener reads the fields of a frame sealed by FrameSealer. Fields must
+// be read in the order they were written. Errors are sticky: once a read
+// fails, all subsequent reads return zero values and Err reports the error.
+type FrameOpener struct {
+	buf []byte
+	err error
+}
+
+// OpenFrame opens a frame sealed by FrameSealer.
+func OpenFrame(c cipher.AEAD, nonce, ciphertext, data []byte) (*FrameOpener, error) {
+	buf, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FrameOpener{buf: buf}, nil
+}
+
+func (f *FrameOpener) next(n int) []byte {
+	if f.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(f.buf) {
+		f.err = ErrInvalidFrame
+		return nil
+	}
+
+	b := f.buf[:n:n]
+	f.buf = f.buf[n:]
+	return b
+}
+
+// Uint8 reads a uint8 from the frame.
+func (f *FrameOpener) Uint8() uint8 {
+	if b := f.next(1); b != nil {
+		return b[0]
+	}
+
+	return 0
+}
+
+// Uint16 reads a uint16 from the frame.
+func (f *FrameOpener) Uint16() uint16 {
+	if b := f.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+
+	return 0
+}
+
+// Uint32 reads a uint32 from the frame.
+func (f *FrameOpener) Uint32() uint32 {
+	if b := f.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+// Uint64 reads a uint64 from the frame.
+func (f *FrameOpener) Uint64() uint64 {
+	if b := f.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+
+	return 0
+}
+
+// Bytes reads a length-prefixed byte string from the frame. The returned
+// slice aliases the frame.
+func (f *FrameOpener) Bytes() []byte {
+	n := f.Uint32()
+	if f.err != nil || uint64(n) > uint64(len(f.buf)) {
+		f.err = ErrInvalidFrame
+		return nil
+	}
+
+	return f.next(int(n))
+}
+
+// Remaining returns the number of unread bytes in the frame.
+func (f *FrameOpener) Remaining() int {
+	return len(f.buf)
+}
+
+// Err returns the first error encountered while reading the frame.
+func (f *FrameOpener) Err() error {
+	return f.err
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	var fs FrameSealer
+	ciphertext := fs.Uint8(0x12).
+		Bytes([]byte("yay for me")).
+		Uint16(0x3456).
+		Uint32(0x789abcde).
+		Bytes(nil).
+		Uint64(0x0123456789abcdef).
+		Seal(c, nil, nonce, data)
+
+	f, err := OpenFrame(c, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := f.Uint8(); v != 0x12 {
+		t.Errorf("Bad uint8: expected %#x, was %#x", 0x12, v)
+	}
+
+	if v := f.Bytes(); !bytes.Equal(v, []byte("yay for me")) {
+		t.Errorf("Bad bytes: expected %x, was %x", "yay for me", v)
+	}
+
+	if v := f.Uint16(); v != 0x3456 {
+		t.Errorf("Bad uint16: expected %#x, was %#x", 0x3456, v)
+	}
+
+	if v := f.Uint32(); v != 0x789abcde {
+		t.Errorf("Bad uint32: expected %#x, was %#x", 0x789abcde, v)
+	}
+
+	if v := f.Bytes(); len(v) != 0 {
+		t.Errorf("Bad bytes: expected empty, was %x", v)
+	}
+
+	if v := f.Uint64(); v != 0x0123456789abcdef {
+		t.Errorf("Bad uint64: expected %#x, was %#x", uint64(0x0123456789abcdef), v)
+	}
+
+	if err := f.Err(); err != nil {
+		t.Error(err)
+	}
+
+	if f.Remaining() != 0 {
+		t.Errorf("Expected no remaining bytes but was %d", f.Remaining())
+	}
+
+	if f.Uint8(); f.Err() != ErrInvalidFrame {
+		t.Errorf("Expected invalid frame error but was %v", f.Err())
+	}
+
+	ciphertext[0] ^= 1
+
+	if _, err = OpenFrame(c, nonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestFrameInvalidLength(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+
+	var fs FrameSealer
+	ciphertext := fs.Uint32(100).Seal(c, nil, nonce, nil)
+
+	f, err := OpenFrame(c, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := f.Bytes(); v != nil || f.Err() != ErrInvalidFrame {
+		t.Errorf("Expected invalid frame error but was %v (%x)", f.Err(), v)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	codahale "github.com/codahale/chacha20poly1305"
+	"github.com/tmthrgd/chacha20"
+)
+
+func FuzzDraftCompat(f *testing.F) {
+	for _, vector := range draftTestVectors {
+		f.Add(vector.key, vector.nonce, vector.plaintext, vector.data)
+	}
+
+	f.Add(make([]byte, KeySize), make([]byte, chacha20.DraftNonceSize), []byte{}, []byte{})
+
+	f.Fuzz(func(t *testing.T, key, nonce, plaintext, data []byte) {
+		if len(key) != KeySize || len(nonce) != chacha20.DraftNonceSize {
+			t.Skip()
+		}
+
+		old, err := codahale.New(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := NewDraft(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := old.Seal(nil, nonce, plaintext, data)
+
+		actual := c.Seal(nil, nonce, plaintext, data)
+		if !bytes.Equal(expected, actual) {
+			t.Fatalf("Bad seal: expected %x, was %x", expected, actual)
+		}
+
+		opened, err := c.Open(nil, nonce, expected, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Fatalf("Bad open: expected %x, was %x", plaintext, opened)
+		}
+
+		if opened, err = old.Open(nil, nonce, actual, data); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Fatalf("Bad open: expected %x, was %x", plaintext, opened)
+		}
+	})
+}
+
+func FuzzOpen(f *testing.F) {
+	for _, vector := range rfcTestVectors {
+		f.Add(vector.key, vector.nonce, vector.ciphertext, vector.data)
+	}
+
+	for _, vector := range draftTestVectors {
+		f.Add(vector.key, vector.nonce, vector.ciphertext, vector.data)
+	}
+
+	f.Add(make([]byte, KeySize), make([]byte, XNonceSize), make([]byte, TagSize), []byte{})
+	f.Add([]byte{}, []byte{}, []byte{}, []byte{})
+
+	f.Fuzz(func(t *testing.T, key, nonce, ciphertext, data []byte) {
+		for _, newChaCha20Poly1305 := range []func(key []byte) (cipher.AEAD, error){
+			NewRFC,
+			NewDraft,
+			NewX,
+		} {
+			c, err := newChaCha20Poly1305(key)
+			if len(key) != KeySize {
+				if err != ErrInvalidKey {
+					t.Fatalf("Expected invalid key error but was %v", err)
+				}
+
+				continue
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			fuzzOpen(t, c, nonce, ciphertext, data)
+		}
+	})
+}
+
+// fuzzOpen calls c.Open and checks that it only panics on a nonce of the
+// wrong size and that it never returns both plaintext and an error.
+func fuzzOpen(t *testing.T, c cipher.AEAD, nonce, ciphertext, data []byte) {
+	defer func() {
+		r := recover()
+		if len(nonce) != c.NonceSize() {
+			if r != ErrInvalidNonce {
+				t.Fatalf("Expected invalid nonce panic but was %v", r)
+			}
+		} else if r != nil {
+			t.Fatalf("Open panicked: %v", r)
+		}
+	}()
+
+	plaintext, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil && plaintext != nil {
+		t.Fatalf("Open returned plaintext %x with error %v", plaintext, err)
+	}
+
+	if err == nil && len(plaintext) != len(ciphertext)-c.Overhead() {
+		t.Fatalf("Expected %d bytes of plaintext but was %d", len(ciphertext)-c.Overhead(), len(plaintext))
+	}
+}
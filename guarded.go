@@ -0,0 +1,100 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrNonceReused is the panic value of Seal when an AEAD returned by
+// NewRFCGuarded is asked to seal under a nonce it has already used.
+var ErrNonceReused = errors.New("nonce reused")
+
+// guardMaxNonces is the number of nonces remembered by an AEAD returned from
+// NewRFCGuarded. It bounds the memory used by the remembered nonces.
+const guardMaxNonces = 1 << 20
+
+// NewRFCGuarded behaves like NewRFC but the returned cipher remembers each
+// nonce it has sealed under and panics with ErrNonceReused if one is used
+// again. Only the first 2^20 nonces are remembered; reuse of a nonce after
+// that is not detected.
+//
+// Every Seal takes a lock and the remembered nonces are never freed, so this
+// is intended to catch nonce reuse in tests and staging environments, not for
+// use in production.
+func NewRFCGuarded(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	k := &guardedKey{guard: &nonceGuard{
+		seen: make(map[[RFCNonceSize]byte]struct{}),
+	}}
+	copy(k.k.key[:], key)
+	return k, nil
+}
+
+type guardedKey struct {
+	k chacha20Key
+
+	guard *nonceGuard // shared with clones
+}
+
+type nonceGuard struct {
+	mu   sync.Mutex
+	seen map[[RFCNonceSize]byte]struct{}
+}
+
+// use records nonce and reports whether it had already been used.
+func (g *nonceGuard) use(nonce []byte) (reused bool) {
+	var n [RFCNonceSize]byte
+	copy(n[:], nonce)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, reused = g.seen[n]; !reused && len(g.seen) < guardMaxNonces {
+		g.seen[n] = struct{}{}
+	}
+
+	return reused
+}
+
+func (k *guardedKey) NonceSize() int {
+	return RFCNonceSize
+}
+
+func (k *guardedKey) Overhead() int {
+	return poly1305.TagSize
+}
+
+func (k *guardedKey) Seal(dst, nonce, plaintext, data []byte) []byte {
+	if err := k.k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	if k.guard.use(nonce) {
+		panic(ErrNonceReused)
+	}
+
+	ret, err := k.k.sealSafe(1, dst, nonce, plaintext, data)
+	if err != nil {
+		panic(err)
+	}
+
+	return ret
+}
+
+func (k *guardedKey) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	return k.k.Open(dst, nonce, ciphertext, data)
+}
+
+func (k *guardedKey) Destroy() {
+	k.k.Destroy()
+}
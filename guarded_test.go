@@ -0,0 +1,67 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRFCGuarded(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFCGuarded(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rfc, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	for i := 0; i < 100; i++ {
+		nonce := make([]byte, c.NonceSize())
+		nonce[0], nonce[11] = byte(i), byte(i>>8)
+
+		actual := c.Seal(nil, nonce, plaintext, data)
+		if expected := rfc.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+		}
+
+		opened, err := c.Open(nil, nonce, actual, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, opened) {
+			t.Errorf("Bad open: expected %x, was %x", plaintext, opened)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r != ErrNonceReused {
+			t.Errorf("Expected nonce reused panic but was %v", r)
+		}
+	}()
+
+	nonce := make([]byte, c.NonceSize())
+	nonce[0] = 42
+
+	c.Seal(nil, nonce, []byte("something else"), nil)
+}
+
+func TestRFCGuardedInvalidKey(t *testing.T) {
+	if _, err := NewRFCGuarded(make([]byte, KeySize-1)); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestRFCGuardedDestroy(t *testing.T) {
+	testDestroy(t, NewRFCGuarded, "k", "key")
+}
@@ -0,0 +1,31 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import xchacha20 "golang.org/x/crypto/chacha20"
+
+// HChaCha20 returns the 32-byte output of the HChaCha20 function, as
+// described in draft-irtf-cfrg-xchacha, for the given 32-byte key and
+// 16-byte nonce. It is the subkey derivation used by NewX and may be used to
+// build other extended-nonce constructions and key hierarchies.
+//
+// ErrInvalidKey or ErrInvalidNonce is returned if key or nonce is the wrong
+// size.
+func HChaCha20(key, nonce []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(nonce) != 16 {
+		return nil, ErrInvalidNonce
+	}
+
+	subkey, err := xchacha20.HChaCha20(key, nonce)
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	return subkey, nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHChaCha20(t *testing.T) {
+	// From draft-irtf-cfrg-xchacha-03, section 2.2.1.
+	key := mustHexDecode("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	nonce := mustHexDecode("000000090000004a0000000031415927")
+	expected := mustHexDecode("82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc")
+
+	actual, err := HChaCha20(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("Bad HChaCha20: expected %x, was %x", expected, actual)
+	}
+
+	if _, err := HChaCha20(key[:31], nonce); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	if _, err := HChaCha20(key, nonce[:12]); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// bindHeaderData returns nonce || len(header) || header || data, where the
+// length is an 8-byte, little-endian value.
+func bindHeaderData(nonce, header, data []byte) []byte {
+	ad := make([]byte, 0, len(nonce)+8+len(header)+len(data))
+	ad = append(ad, nonce...)
+
+	var l [8]byte
+	binary.LittleEndian.PutUint64(l[:], uint64(len(header)))
+	ad = append(ad, l[:]...)
+
+	ad = append(ad, header...)
+	return append(ad, data...)
+}
+
+// SealBindHeader behaves like c.Seal but additionally authenticates nonce and
+// header as a unit, by prefixing the additional data with the nonce, the
+// 8-byte, little-endian length of header and header itself. The result will
+// only open with OpenBindHeader given the same nonce and header.
+//
+// This is a NON-STANDARD use of the additional data. The standard
+// construction already binds the nonce implicitly, as it determines the
+// Poly1305 key; this makes the binding of the nonce and header explicit in
+// the MAC input so the triple can't be recombined.
+func SealBindHeader(c cipher.AEAD, dst, nonce, header, plaintext, data []byte) []byte {
+	return c.Seal(dst, nonce, plaintext, bindHeaderData(nonce, header, data))
+}
+
+// OpenBindHeader opens a ciphertext produced by SealBindHeader.
+func OpenBindHeader(c cipher.AEAD, dst, nonce, header, ciphertext, data []byte) ([]byte, error) {
+	return c.Open(dst, nonce, ciphertext, bindHeaderData(nonce, header, data))
+}
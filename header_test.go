@@ -0,0 +1,61 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBindHeader(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonceA := make([]byte, c.NonceSize())
+	nonceB := make([]byte, c.NonceSize())
+	nonceB[0] = 1
+
+	headerA := []byte("header a")
+	headerB := []byte("header b")
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertextA := SealBindHeader(c, nil, nonceA, headerA, plaintext, data)
+	ciphertextB := SealBindHeader(c, nil, nonceB, headerB, plaintext, data)
+
+	actual, err := OpenBindHeader(c, nil, nonceA, headerA, ciphertextA, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	for _, test := range []struct {
+		name              string
+		nonce, header, ct []byte
+	}{
+		{"header swapped", nonceA, headerB, ciphertextA},
+		{"nonce swapped", nonceB, headerA, ciphertextA},
+		{"ciphertext swapped", nonceA, headerA, ciphertextB},
+		{"header moved into data", nonceA, nil, ciphertextA},
+	} {
+		if _, err := OpenBindHeader(c, nil, test.nonce, test.header, test.ct, data); err != ErrAuthFailed {
+			t.Errorf("%s: expected message authentication failed error but was %v", test.name, err)
+		}
+	}
+
+	// Moving bytes between the header and the data must fail.
+	ciphertext := SealBindHeader(c, nil, nonceA, []byte("ab"), plaintext, []byte("c"))
+	if _, err = OpenBindHeader(c, nil, nonceA, []byte("a"), ciphertext, []byte("bc")); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// kdf returns HMAC-SHA256(key, label || 0x00 || in...), a 32-byte value
+// derived from key for a single purpose. Each purpose has its own label, so
+// no derived value can collide with another, nor with ChaCha20 keystream or an
+// HChaCha20 subkey, which use key directly.
+func kdf(key []byte, label string, in ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(label))
+	h.Write([]byte{0})
+
+	for _, b := range in {
+		h.Write(b)
+	}
+
+	return h.Sum(nil)
+}
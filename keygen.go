@@ -0,0 +1,32 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// GenerateKey returns a new 256-bit key read from crypto/rand.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if err := GenerateKeyInto(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GenerateKeyInto fills dst, which must be exactly 256 bits long, with a new
+// key read from crypto/rand. ErrInvalidKey is returned if dst is the wrong
+// size.
+func GenerateKeyInto(dst []byte) error {
+	if len(dst) != KeySize {
+		return ErrInvalidKey
+	}
+
+	_, err := io.ReadFull(rand.Reader, dst)
+	return err
+}
@@ -0,0 +1,49 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	a, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a) != KeySize || len(b) != KeySize {
+		t.Fatalf("Expected keys of %d bytes but were %d and %d", KeySize, len(a), len(b))
+	}
+
+	if bytes.Equal(a, b) {
+		t.Errorf("Expected generated keys to differ, both were %x", a)
+	}
+
+	if _, err := NewRFC(a); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGenerateKeyInto(t *testing.T) {
+	key := make([]byte, KeySize)
+	if err := GenerateKeyInto(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(key, make([]byte, KeySize)) {
+		t.Error("Expected key to be filled")
+	}
+
+	if err := GenerateKeyInto(make([]byte, KeySize-1)); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
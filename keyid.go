@@ -0,0 +1,31 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// keyIDLabel is the kdf label used to derive key fingerprints.
+const keyIDLabel = "chacha20poly1305 key id"
+
+// KeyIDAEAD is implemented by the AEADs returned from NewRFC and NewDraft. It
+// exposes a stable fingerprint of the key for audit logging.
+type KeyIDAEAD interface {
+	cipher.AEAD
+
+	// KeyID returns a 32-byte fingerprint of the key, computed as
+	// HMAC-SHA256(key, "chacha20poly1305 key id" || 0x00). It is
+	// deterministic for a given key and reveals nothing about the key
+	// itself. Unlike an HChaCha20 output, it can't equal a NewX subkey. It
+	// is a fingerprint of the key only and does not authenticate any data.
+	KeyID() []byte
+}
+
+func (k *chacha20Key) KeyID() []byte {
+	if k.destroyed {
+		panic(ErrInvalidKey)
+	}
+
+	return kdf(k.key[:], keyIDLabel)
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"testing"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+func TestKeyID(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	a, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key[0] = 1
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aID := a.(KeyIDAEAD).KeyID()
+	bID := b.(KeyIDAEAD).KeyID()
+	cID := c.(KeyIDAEAD).KeyID()
+
+	if len(aID) != 32 {
+		t.Errorf("Expected key id of 32 bytes but was %d", len(aID))
+	}
+
+	if !bytes.Equal(aID, bID) {
+		t.Errorf("Expected identical keys to share a key id: %x vs %x", aID, bID)
+	}
+
+	if bytes.Equal(aID, cID) {
+		t.Errorf("Expected different keys to have different key ids: %x", aID)
+	}
+
+	if bytes.Contains(cID, key) {
+		t.Error("Key id contains the key")
+	}
+}
+
+func TestKeyIDDerivation(t *testing.T) {
+	key := bytes.Repeat([]byte{0xff}, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := c.(KeyIDAEAD).KeyID()
+
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte("chacha20poly1305 key id\x00"))
+
+	if expected := h.Sum(nil); !bytes.Equal(expected, id) {
+		t.Errorf("Bad key id: expected %x, was %x", expected, id)
+	}
+
+	// The key id must not be the subkey that NewX derives for any nonce.
+	subkey, err := xchacha20.HChaCha20(key, []byte("key id\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Equal(subkey, id) {
+		t.Error("Key id is a NewX subkey")
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrInvalidLayout is returned when a LayoutSpec does not name each of the
+// nonce, ciphertext and tag exactly once.
+var ErrInvalidLayout = errors.New("invalid layout: must contain nonce, ciphertext and tag exactly once")
+
+// TagPosition describes where the authentication tag is placed in the output
+// of Seal.
+type TagPosition int
+
+const (
+	// TagSuffix means the tag follows the ciphertext.
+	TagSuffix TagPosition = iota
+
+	// TagPrefix means the tag precedes the ciphertext.
+	TagPrefix
+)
+
+func (p TagPosition) String() string {
+	switch p {
+	case TagSuffix:
+		return "suffix"
+	case TagPrefix:
+		return "prefix"
+	default:
+		return "unknown"
+	}
+}
+
+// Layout describes the output format of an AEAD.
+type Layout struct {
+	// NonceSize is the size of the nonce in bytes.
+	NonceSize int
+
+	// NoncePrepended is true if the nonce is prepended to the output.
+	NoncePrepended bool
+
+	// TagPosition is the position of the tag relative to the ciphertext.
+	TagPosition TagPosition
+
+	// TagSize is the size of the tag in bytes.
+	TagSize int
+}
+
+// LayoutAEAD is implemented by the AEADs returned from NewRFC and NewDraft. It
+// allows the output format of a configured AEAD to be introspected.
+type LayoutAEAD interface {
+	cipher.AEAD
+
+	// Layout returns the output format of Seal.
+	Layout() Layout
+}
+
+func (k *chacha20Key) Layout() Layout {
+	return Layout{
+		NonceSize:   k.NonceSize(),
+		TagPosition: TagSuffix,
+		TagSize:     k.Overhead(),
+	}
+}
+
+// LayoutField is a single field of a sealed message.
+type LayoutField int
+
+const (
+	// FieldNonce is the nonce.
+	FieldNonce LayoutField = iota
+
+	// FieldCiphertext is the encrypted plaintext.
+	FieldCiphertext
+
+	// FieldTag is the authentication tag.
+	FieldTag
+)
+
+func (f LayoutField) String() string {
+	switch f {
+	case FieldNonce:
+		return "nonce"
+	case FieldCiphertext:
+		return "ciphertext"
+	case FieldTag:
+		return "tag"
+	default:
+		return "unknown"
+	}
+}
+
+// LayoutSpec describes the order in which the fields of a sealed message are
+// written by SealLayout and read by OpenLayout.
+type LayoutSpec struct {
+	Order [3]LayoutField
+}
+
+var (
+	// NoncePrefixLayout is nonce || ciphertext || tag.
+	NoncePrefixLayout = LayoutSpec{[3]LayoutField{FieldNonce, FieldCiphertext, FieldTag}}
+
+	// TagPrefixLayout is nonce || tag || ciphertext.
+	TagPrefixLayout = LayoutSpec{[3]LayoutField{FieldNonce, FieldTag, FieldCiphertext}}
+
+	// TagFirstLayout is tag || nonce || ciphertext.
+	TagFirstLayout = LayoutSpec{[3]LayoutField{FieldTag, FieldNonce, FieldCiphertext}}
+
+	// NonceSuffixLayout is ciphertext || tag || nonce.
+	NonceSuffixLayout = LayoutSpec{[3]LayoutField{FieldCiphertext, FieldTag, FieldNonce}}
+)
+
+func (s LayoutSpec) valid() bool {
+	var seen [3]bool
+	for _, f := range s.Order {
+		if f < FieldNonce || f > FieldTag || seen[f] {
+			return false
+		}
+
+		seen[f] = true
+	}
+
+	return true
+}
+
+// SealLayout seals plaintext with c and appends the nonce, ciphertext and tag
+// to dst in the order given by spec. c must place its tag after the
+// ciphertext, as all the AEADs in this package and the standard library do.
+func SealLayout(c cipher.AEAD, spec LayoutSpec, dst, nonce, plaintext, data []byte) ([]byte, error) {
+	if !spec.valid() {
+		return nil, ErrInvalidLayout
+	}
+
+	sealed := c.Seal(nil, nonce, plaintext, data)
+	tagStart := len(sealed) - c.Overhead()
+
+	for _, f := range spec.Order {
+		switch f {
+		case FieldNonce:
+			dst = append(dst, nonce...)
+		case FieldCiphertext:
+			dst = append(dst, sealed[:tagStart]...)
+		case FieldTag:
+			dst = append(dst, sealed[tagStart:]...)
+		}
+	}
+
+	return dst, nil
+}
+
+// OpenLayout parses sealed according to spec and opens it with c, appending
+// the plaintext to dst. ErrCiphertextTooShort is returned if sealed is too
+// short to contain a nonce and tag.
+func OpenLayout(c cipher.AEAD, spec LayoutSpec, dst, sealed, data []byte) ([]byte, error) {
+	if !spec.valid() {
+		return nil, ErrInvalidLayout
+	}
+
+	nonceSize, tagSize := c.NonceSize(), c.Overhead()
+	if len(sealed) < nonceSize+tagSize {
+		return nil, ErrCiphertextTooShort
+	}
+
+	var nonce, ciphertext, tag []byte
+	for _, f := range spec.Order {
+		switch f {
+		case FieldNonce:
+			nonce, sealed = sealed[:nonceSize], sealed[nonceSize:]
+		case FieldCiphertext:
+			n := len(sealed)
+			if nonce == nil {
+				n -= nonceSize
+			}
+
+			if tag == nil {
+				n -= tagSize
+			}
+
+			ciphertext, sealed = sealed[:n], sealed[n:]
+		case FieldTag:
+			tag, sealed = sealed[:tagSize], sealed[tagSize:]
+		}
+	}
+
+	combined := make([]byte, 0, len(ciphertext)+len(tag))
+	combined = append(combined, ciphertext...)
+	combined = append(combined, tag...)
+	return c.Open(dst, nonce, combined, data)
+}
@@ -0,0 +1,120 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/tmthrgd/chacha20"
+	"github.com/tmthrgd/poly1305"
+)
+
+func testLayout(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), expect Layout) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l := c.(LayoutAEAD).Layout(); l != expect {
+		t.Errorf("Expected layout of %+v but was %+v", expect, l)
+	}
+}
+
+func TestRFCLayout(t *testing.T) {
+	testLayout(t, NewRFC, Layout{
+		NonceSize:   chacha20.RFCNonceSize,
+		TagPosition: TagSuffix,
+		TagSize:     poly1305.TagSize,
+	})
+}
+
+func TestDraftLayout(t *testing.T) {
+	testLayout(t, NewDraft, Layout{
+		NonceSize:   chacha20.DraftNonceSize,
+		TagPosition: TagSuffix,
+		TagSize:     poly1305.TagSize,
+	})
+}
+
+func TestSealLayout(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	sealed := c.Seal(nil, nonce, plaintext, data)
+	ciphertext, tag := sealed[:len(plaintext)], sealed[len(plaintext):]
+
+	for _, test := range []struct {
+		name   string
+		spec   LayoutSpec
+		expect [][]byte
+	}{
+		{"NoncePrefix", NoncePrefixLayout, [][]byte{nonce, ciphertext, tag}},
+		{"TagPrefix", TagPrefixLayout, [][]byte{nonce, tag, ciphertext}},
+		{"TagFirst", TagFirstLayout, [][]byte{tag, nonce, ciphertext}},
+		{"NonceSuffix", NonceSuffixLayout, [][]byte{ciphertext, tag, nonce}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := SealLayout(c, test.spec, nil, nonce, plaintext, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if expect := bytes.Join(test.expect, nil); !bytes.Equal(expect, out) {
+				t.Errorf("Bad seal: expected %x, was %x", expect, out)
+			}
+
+			actual, err := OpenLayout(c, test.spec, nil, out, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(plaintext, actual) {
+				t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+			}
+
+			out[len(out)-1] ^= 1
+
+			if _, err := OpenLayout(c, test.spec, nil, out, data); err != ErrAuthFailed {
+				t.Errorf("Expected message authentication failed error but was %v", err)
+			}
+
+			if _, err := OpenLayout(c, test.spec, nil, out[:c.NonceSize()+c.Overhead()-1], data); err != ErrCiphertextTooShort {
+				t.Errorf("Expected ciphertext too short error but was %v", err)
+			}
+		})
+	}
+}
+
+func TestSealLayoutInvalid(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spec := LayoutSpec{[3]LayoutField{FieldNonce, FieldNonce, FieldTag}}
+	nonce := make([]byte, c.NonceSize())
+
+	if _, err := SealLayout(c, spec, nil, nonce, nil, nil); err != ErrInvalidLayout {
+		t.Errorf("Expected invalid layout error but was %v", err)
+	}
+
+	if _, err := OpenLayout(c, spec, nil, make([]byte, 64), nil); err != ErrInvalidLayout {
+		t.Errorf("Expected invalid layout error but was %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// macWriter incrementally computes the same tag as auth. The additional data
+// must be written with writeData before any ciphertext is written with Write.
+type macWriter struct {
+	mac poly1305.MAC
+
+	draft bool
+
+	dataLen, ciphertextLen uint64
+}
+
+func (k *chacha20Key) newMACWriter(pk []byte) macWriter {
+	var pkey [32]byte
+	copy(pkey[:], pk)
+
+	return macWriter{
+		mac:   *poly1305.New(&pkey),
+		draft: k.draft,
+	}
+}
+
+func (w *macWriter) writeData(data []byte) {
+	w.mac.Write(data)
+	w.dataLen += uint64(len(data))
+}
+
+// writeEncodedData writes additional data of dataLen bytes that has already
+// been encoded, as by writeData and endData.
+func (w *macWriter) writeEncodedData(encoded []byte, dataLen uint64) {
+	w.mac.Write(encoded)
+	w.dataLen = dataLen
+}
+
+// endData must be called after all the additional data has been written and
+// before any ciphertext is written.
+func (w *macWriter) endData() {
+	if w.draft {
+		w.writeLen(w.dataLen)
+	} else {
+		w.pad(w.dataLen)
+	}
+}
+
+func (w *macWriter) Write(ciphertext []byte) (int, error) {
+	w.mac.Write(ciphertext)
+	w.ciphertextLen += uint64(len(ciphertext))
+	return len(ciphertext), nil
+}
+
+// sum finishes the MAC and writes the tag into out.
+func (w *macWriter) sum(out []byte) {
+	if w.draft {
+		w.writeLen(w.ciphertextLen)
+	} else {
+		w.pad(w.ciphertextLen)
+		w.writeLen(w.dataLen)
+		w.writeLen(w.ciphertextLen)
+	}
+
+	w.mac.Sum(out[:0])
+}
+
+func (w *macWriter) pad(n uint64) {
+	var zero [poly1305PadLen]byte
+	w.mac.Write(zero[:Pad16Len(int(n%poly1305PadLen))])
+}
+
+func (w *macWriter) writeLen(n uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], n)
+	w.mac.Write(b[:])
+}
@@ -0,0 +1,268 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+var (
+	// ErrUnsupportedVersion is returned when unmarshaling an AEAD that
+	// was encoded with an unknown version of the binary encoding.
+	ErrUnsupportedVersion = errors.New("unsupported AEAD encoding version")
+
+	// ErrInvalidEncoding is returned when unmarshaling an AEAD from a
+	// malformed encoding, or from the encoding of a different kind of
+	// AEAD.
+	ErrInvalidEncoding = errors.New("invalid AEAD encoding")
+
+	// ErrNotMarshalable is returned when marshaling an AEAD created by
+	// NewRFCWithCompare, as its compare function can't be encoded.
+	ErrNotMarshalable = errors.New("AEAD with a custom compare function can't be marshaled")
+)
+
+// MarshalableAEAD is implemented by the AEADs returned from New, NewRFC,
+// NewDraft, NewX, NewWithOptions, NewRFCTruncatedTag and most of the other
+// RFC7539 and draft constructors. It allows a configured AEAD to be persisted
+// and later restored, with UnmarshalAEAD or UnmarshalBinary, without
+// re-specifying its options.
+//
+// The encoding contains the key in the clear and must be protected
+// accordingly.
+type MarshalableAEAD interface {
+	cipher.AEAD
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// The binary encoding is, in order:
+//
+//	version   1 byte, marshalVersion
+//	mode      1 byte, a Mode
+//	flags     1 byte, of marshalFlag*
+//	tagLen    1 byte
+//	counter   4 bytes, little-endian
+//	maxLen    8 bytes, little-endian
+//	maxAADLen 8 bytes, little-endian
+//	salt      12 bytes
+//	key       32 bytes
+const (
+	marshalVersion = 1
+
+	marshalLen = 4 + 4 + 8 + 8 + 12 + KeySize
+)
+
+const (
+	marshalFlagSalted = 1 << iota
+	marshalFlagNoPool
+	marshalFlagCommit
+
+	marshalFlagMask = marshalFlagSalted | marshalFlagNoPool | marshalFlagCommit
+)
+
+// marshalKey encodes k along with the given mode, flags and tag length.
+func (k *chacha20Key) marshalKey(mode Mode, flags byte, tagLen int) ([]byte, error) {
+	if k.destroyed {
+		return nil, ErrInvalidKey
+	}
+
+	if k.compare != nil {
+		return nil, ErrNotMarshalable
+	}
+
+	if k.salted {
+		flags |= marshalFlagSalted
+	}
+
+	if k.noPool {
+		flags |= marshalFlagNoPool
+	}
+
+	b := make([]byte, marshalLen)
+	b[0], b[1], b[2], b[3] = marshalVersion, byte(mode), flags, byte(tagLen)
+	binary.LittleEndian.PutUint32(b[4:], k.counter)
+	binary.LittleEndian.PutUint64(b[8:], uint64(k.maxLen))
+	binary.LittleEndian.PutUint64(b[16:], k.maxAADLen)
+	copy(b[24:], k.salt[:])
+	copy(b[36:], k.key[:])
+	return b, nil
+}
+
+// unmarshalKey decodes data into k and returns the mode, flags and tag
+// length it was encoded with. The fields of k are only meaningful for
+// ModeRFC and ModeDraft.
+func unmarshalKey(data []byte) (k chacha20Key, mode Mode, flags byte, tagLen int, err error) {
+	if len(data) == 0 {
+		return k, 0, 0, 0, ErrInvalidEncoding
+	}
+
+	if data[0] != marshalVersion {
+		return k, 0, 0, 0, ErrUnsupportedVersion
+	}
+
+	if len(data) != marshalLen {
+		return k, 0, 0, 0, ErrInvalidEncoding
+	}
+
+	mode, flags, tagLen = Mode(data[1]), data[2], int(data[3])
+
+	k.draft = mode == ModeDraft
+	k.salted = flags&marshalFlagSalted != 0
+	k.noPool = flags&marshalFlagNoPool != 0
+	k.counter = binary.LittleEndian.Uint32(data[4:])
+	maxLen := binary.LittleEndian.Uint64(data[8:])
+	k.maxAADLen = binary.LittleEndian.Uint64(data[16:])
+	copy(k.salt[:], data[24:])
+	copy(k.key[:], data[36:])
+
+	var zeroSalt [len(k.salt)]byte
+
+	switch {
+	case mode != ModeRFC && mode != ModeDraft && mode != ModeX,
+		flags&^marshalFlagMask != 0,
+		tagLen < minTruncatedTagSize || tagLen > poly1305.TagSize,
+		maxLen > uint64(^uint(0)>>1),
+		mode != ModeRFC && (k.salted || k.counter > 1),
+		!k.salted && k.salt != zeroSalt,
+		mode == ModeX && (flags != 0 || k.counter != 0 || maxLen != 0 || k.maxAADLen != 0):
+		wipe(k.key[:])
+		return chacha20Key{}, 0, 0, 0, ErrInvalidEncoding
+	}
+
+	k.maxLen = int(maxLen)
+	return k, mode, flags, tagLen, nil
+}
+
+// UnmarshalAEAD restores an AEAD from the output of its MarshalBinary method.
+// ErrUnsupportedVersion is returned if data was encoded by an unknown version
+// of this package and ErrInvalidEncoding if it is otherwise malformed.
+func UnmarshalAEAD(data []byte) (cipher.AEAD, error) {
+	k, mode, flags, tagLen, err := unmarshalKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case mode == ModeX:
+		xk := new(xchacha20Key)
+		copy(xk.key[:], k.key[:])
+		wipe(k.key[:])
+		return xk, nil
+	case flags&marshalFlagCommit != 0:
+		if tagLen != poly1305.TagSize {
+			wipe(k.key[:])
+			return nil, ErrInvalidEncoding
+		}
+
+		return &committingKey{k: k}, nil
+	case tagLen != poly1305.TagSize:
+		if k.draft {
+			wipe(k.key[:])
+			return nil, ErrInvalidEncoding
+		}
+
+		return &truncatedTagKey{k: k, tagLen: tagLen}, nil
+	default:
+		return &k, nil
+	}
+}
+
+// unmarshalInto restores an AEAD from data and, if it is of the same kind as
+// dst, copies it into dst.
+func unmarshalInto(dst cipher.AEAD, data []byte) error {
+	c, err := UnmarshalAEAD(data)
+	if err != nil {
+		return err
+	}
+
+	switch dst := dst.(type) {
+	case *chacha20Key:
+		if c, ok := c.(*chacha20Key); ok {
+			*dst = *c
+			return nil
+		}
+	case *xchacha20Key:
+		if c, ok := c.(*xchacha20Key); ok {
+			*dst = *c
+			return nil
+		}
+	case *committingKey:
+		if c, ok := c.(*committingKey); ok {
+			*dst = *c
+			return nil
+		}
+	case *truncatedTagKey:
+		switch c := c.(type) {
+		case *truncatedTagKey:
+			*dst = *c
+			return nil
+		case *chacha20Key:
+			// NewRFCTruncatedTag with a tagLen of 16 is encoded
+			// identically to NewRFC.
+			if !c.draft {
+				*dst = truncatedTagKey{k: *c, tagLen: poly1305.TagSize}
+				return nil
+			}
+		}
+	}
+
+	c.(DestroyableAEAD).Destroy()
+	return ErrInvalidEncoding
+}
+
+func (k *chacha20Key) MarshalBinary() ([]byte, error) {
+	mode := ModeRFC
+	if k.draft {
+		mode = ModeDraft
+	}
+
+	return k.marshalKey(mode, 0, poly1305.TagSize)
+}
+
+func (k *chacha20Key) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(k, data)
+}
+
+func (k *xchacha20Key) MarshalBinary() ([]byte, error) {
+	if k.destroyed {
+		return nil, ErrInvalidKey
+	}
+
+	var ck chacha20Key
+	copy(ck.key[:], k.key[:])
+	defer wipe(ck.key[:])
+
+	return ck.marshalKey(ModeX, 0, poly1305.TagSize)
+}
+
+func (k *xchacha20Key) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(k, data)
+}
+
+func (k *committingKey) MarshalBinary() ([]byte, error) {
+	mode := ModeRFC
+	if k.k.draft {
+		mode = ModeDraft
+	}
+
+	return k.k.marshalKey(mode, marshalFlagCommit, poly1305.TagSize)
+}
+
+func (k *committingKey) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(k, data)
+}
+
+func (k *truncatedTagKey) MarshalBinary() ([]byte, error) {
+	return k.k.marshalKey(ModeRFC, 0, k.tagLen)
+}
+
+func (k *truncatedTagKey) UnmarshalBinary(data []byte) error {
+	return unmarshalInto(k, data)
+}
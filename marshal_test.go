@@ -0,0 +1,158 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"reflect"
+	"testing"
+)
+
+var marshalTests = []struct {
+	name string
+	new  func(key []byte) (cipher.AEAD, error)
+}{
+	{"RFC", NewRFC},
+	{"Draft", NewDraft},
+	{"X", NewX},
+	{"RFCWithCounter", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCWithCounter(key, 42)
+	}},
+	{"RFCCommitting", NewRFCCommitting},
+	{"DraftCommitting", func(key []byte) (cipher.AEAD, error) {
+		return NewWithOptions(key, WithMode(ModeDraft), WithKeyCommitment())
+	}},
+	{"RFCTruncatedTag", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCTruncatedTag(key, 12)
+	}},
+	{"RFCTruncatedTag16", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCTruncatedTag(key, 16)
+	}},
+	{"RFCSalted", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCSalted(key, []byte("salt salt sa"))
+	}},
+	{"RFCWithMaxLen", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCWithMaxLen(key, 1024)
+	}},
+	{"RFCWithMaxAADLen", func(key []byte) (cipher.AEAD, error) {
+		return NewRFCWithMaxAADLen(key, 1024)
+	}},
+	{"RFCNoPool", NewRFCNoPool},
+}
+
+func TestMarshalBinary(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for _, test := range marshalTests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := test.new(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := c.(MarshalableAEAD).MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nonce := make([]byte, c.NonceSize())
+			plaintext := []byte("yay for me")
+			data := []byte("whoah yeah")
+			expected := c.Seal(nil, nonce, plaintext, data)
+
+			restored, err := UnmarshalAEAD(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual := restored.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+				t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+			}
+
+			into, err := test.new(make([]byte, KeySize))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = into.(MarshalableAEAD).UnmarshalBinary(b); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(c, into) {
+				t.Errorf("Expected %#v but was %#v", c, into)
+			}
+		})
+	}
+}
+
+func TestMarshalBinaryErrors(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := c.(MarshalableAEAD).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	future := append([]byte(nil), b...)
+	future[0] = marshalVersion + 1
+
+	if _, err = UnmarshalAEAD(future); err != ErrUnsupportedVersion {
+		t.Errorf("Expected unsupported version error but was %v", err)
+	}
+
+	corrupt := func(edit func(b []byte)) []byte {
+		b := append([]byte(nil), b...)
+		edit(b)
+		return b
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":         nil,
+		"short":         b[:len(b)-1],
+		"long":          append(append([]byte(nil), b...), 0),
+		"mode":          corrupt(func(b []byte) { b[1] = 42 }),
+		"flags":         corrupt(func(b []byte) { b[2] = 0x80 }),
+		"tag length":    corrupt(func(b []byte) { b[3] = 7 }),
+		"draft counter": corrupt(func(b []byte) { b[1], b[4] = byte(ModeDraft), 2 }),
+		"unsalted salt": corrupt(func(b []byte) { b[24] = 1 }),
+	} {
+		if _, err = UnmarshalAEAD(data); err != ErrInvalidEncoding {
+			t.Errorf("%s: expected invalid encoding error but was %v", name, err)
+		}
+	}
+
+	x, err := NewX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = x.(MarshalableAEAD).UnmarshalBinary(b); err != ErrInvalidEncoding {
+		t.Errorf("Expected invalid encoding error but was %v", err)
+	}
+
+	cmp, err := NewRFCWithCompare(key, func(x, y []byte) int { return 1 })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = cmp.(MarshalableAEAD).MarshalBinary(); err != ErrNotMarshalable {
+		t.Errorf("Expected not marshalable error but was %v", err)
+	}
+
+	c.(DestroyableAEAD).Destroy()
+
+	if _, err = c.(MarshalableAEAD).MarshalBinary(); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build !chacha20poly1305debug
+// +build !chacha20poly1305debug
+
+package chacha20poly1305
+
+func checkDst(calldepth int, dst, plaintext []byte, overhead int) {}
@@ -0,0 +1,92 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrReplay may be returned by the check function passed to
+// OpenWithReplayCheck to reject a replayed message.
+var ErrReplay = errors.New("replayed message")
+
+// ErrShortBuffer is returned when a caller-provided destination buffer is too
+// small to hold the output.
+var ErrShortBuffer = errors.New("short buffer")
+
+// OpenAADLen behaves like c.Open but additionally returns the number of bytes
+// of additional data that were authenticated. On success this is always
+// len(data); it is intended as a cross-check for protocols that carry the
+// length of the additional data in their framing.
+func OpenAADLen(c cipher.AEAD, dst, nonce, ciphertext, data []byte) ([]byte, int, error) {
+	out, err := c.Open(dst, nonce, ciphertext, data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return out, len(data), nil
+}
+
+// OpenInPlace authenticates and decrypts ciphertext, which must include the
+// tag, into its own storage. The returned plaintext aliases ciphertext, so no
+// additional buffer is allocated.
+//
+// OpenInPlace always mutates ciphertext. If authentication fails, the
+// plaintext region of ciphertext is overwritten with zeros.
+func OpenInPlace(c cipher.AEAD, nonce, ciphertext, data []byte) ([]byte, error) {
+	return c.Open(ciphertext[:0], nonce, ciphertext, data)
+}
+
+// OpenN authenticates and decrypts ciphertext into dst, rather than
+// appending to it, and returns the number of bytes of plaintext written.
+// ErrShortBuffer is returned, without decrypting, if dst is shorter than the
+// plaintext.
+func OpenN(c cipher.AEAD, dst, nonce, ciphertext, data []byte) (n int, err error) {
+	if len(ciphertext) < c.Overhead() {
+		return 0, ErrCiphertextTooShort
+	}
+
+	if len(dst) < len(ciphertext)-c.Overhead() {
+		return 0, ErrShortBuffer
+	}
+
+	out, err := c.Open(dst[:0], nonce, ciphertext, data)
+	return len(out), err
+}
+
+// OpenLazyAAD behaves like c.Open but the additional data is produced by aad,
+// which is only invoked once the nonce and ciphertext have passed the cheap
+// structural checks. If those checks pass, aad is invoked exactly once;
+// otherwise it is never invoked.
+func OpenLazyAAD(c cipher.AEAD, dst, nonce, ciphertext []byte, aad func() []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		panic(ErrInvalidNonce)
+	}
+
+	if len(ciphertext) < c.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	return c.Open(dst, nonce, ciphertext, aad())
+}
+
+// OpenWithReplayCheck behaves like c.Open but, only once the message has been
+// authenticated, calls check with the nonce. If check returns an error, the
+// decrypted plaintext is zeroed and that error is returned. This sequences
+// authentication, replay detection and release of the plaintext.
+func OpenWithReplayCheck(c cipher.AEAD, nonce, ciphertext, data []byte, check func(nonce []byte) error) ([]byte, error) {
+	out, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := check(nonce); err != nil {
+		wipe(out)
+		return nil, err
+	}
+
+	return out, nil
+}
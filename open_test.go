@@ -0,0 +1,196 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestOpenAADLen(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	actual, n, err := OpenAADLen(c, nil, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if n != len(data) {
+		t.Errorf("Expected additional data length of %d but was %d", len(data), n)
+	}
+
+	if _, n, err = OpenAADLen(c, nil, nonce, ciphertext, data[1:]); err != ErrAuthFailed || n != 0 {
+		t.Errorf("Expected message authentication failed error but was %v (%d)", err, n)
+	}
+}
+
+func TestOpenInPlace(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		c, err := NewRFC(vector.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ciphertext := append([]byte(nil), vector.ciphertext...)
+
+		actual, err := OpenInPlace(c, vector.nonce, ciphertext, vector.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(vector.plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+		}
+
+		if &actual[0] != &ciphertext[0] {
+			t.Error("OpenInPlace did not decrypt in place")
+		}
+
+		ciphertext = append(ciphertext[:0], vector.ciphertext...)
+		ciphertext[0] ^= 1
+
+		if _, err = OpenInPlace(c, vector.nonce, ciphertext, vector.data); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error but was %v", err)
+		}
+	}
+}
+
+func TestOpenN(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		c, err := NewRFC(vector.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dst := make([]byte, len(vector.plaintext))
+
+		n, err := OpenN(c, dst, vector.nonce, vector.ciphertext, vector.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != len(vector.plaintext) {
+			t.Errorf("Expected %d bytes written but was %d", len(vector.plaintext), n)
+		}
+
+		if !bytes.Equal(vector.plaintext, dst[:n]) {
+			t.Errorf("Bad open: expected %x, was %x", vector.plaintext, dst[:n])
+		}
+
+		if _, err = OpenN(c, dst[:len(dst)-1], vector.nonce, vector.ciphertext, vector.data); err != ErrShortBuffer {
+			t.Errorf("Expected short buffer error but was %v", err)
+		}
+
+		ciphertext := append([]byte(nil), vector.ciphertext...)
+		ciphertext[0] ^= 1
+
+		if _, err = OpenN(c, dst, vector.nonce, ciphertext, vector.data); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error but was %v", err)
+		}
+	}
+}
+
+func TestOpenLazyAAD(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	var calls int
+	aad := func() []byte {
+		calls++
+		return data
+	}
+
+	actual, err := OpenLazyAAD(c, nil, nonce, ciphertext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected aad to be invoked once but was invoked %d times", calls)
+	}
+
+	if _, err = OpenLazyAAD(c, nil, nonce, ciphertext[:2], aad); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected aad not to be invoked for short ciphertext but was invoked %d times", calls-1)
+	}
+}
+
+func TestOpenWithReplayCheck(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	seen := make(map[string]bool)
+	check := func(nonce []byte) error {
+		if seen[string(nonce)] {
+			return ErrReplay
+		}
+
+		seen[string(nonce)] = true
+		return nil
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[0] ^= 1
+
+	if _, err = OpenWithReplayCheck(c, nonce, tampered, data, check); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if len(seen) != 0 {
+		t.Error("check was invoked for an unauthenticated message")
+	}
+
+	actual, err := OpenWithReplayCheck(c, nonce, ciphertext, data, check)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if actual, err = OpenWithReplayCheck(c, nonce, ciphertext, data, check); err != ErrReplay || actual != nil {
+		t.Errorf("Expected replayed message error but was %v (%x)", err, actual)
+	}
+}
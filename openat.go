@@ -0,0 +1,96 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrInvalidOffset is returned by OpenAt when the offset is negative or
+// beyond the end of the plaintext.
+var ErrInvalidOffset = errors.New("invalid plaintext offset")
+
+// OpenAtAEAD is implemented by the AEADs returned from NewRFC, NewDraft and
+// NewX. It allows part of a message to be decrypted without decrypting the
+// whole of it.
+type OpenAtAEAD interface {
+	cipher.AEAD
+
+	// OpenAt authenticates the whole of ciphertext, which must include
+	// the tag, and then decrypts only the plaintext from byteOffset
+	// onwards, appending it to dst. byteOffset need not be a multiple of
+	// the ChaCha20 block size. ErrInvalidOffset is returned if byteOffset
+	// is negative or greater than the length of the plaintext.
+	OpenAt(dst, nonce, ciphertext, data []byte, byteOffset int) ([]byte, error)
+}
+
+func (k *chacha20Key) OpenAt(dst, nonce, ciphertext, data []byte, byteOffset int) ([]byte, error) {
+	if err := k.Verify(nonce, ciphertext, data); err != nil {
+		return nil, err
+	}
+
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	if byteOffset < 0 || byteOffset > len(ciphertext) {
+		return nil, ErrInvalidOffset
+	}
+
+	ciphertext = ciphertext[byteOffset:]
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic(errInvalidOverlap)
+	}
+
+	// The draft construction's 64-bit block counter overlaps the first
+	// four bytes of the RFC7539 nonce, so a draft nonce is equivalent to
+	// an RFC7539 nonce with four leading zero bytes for as long as the
+	// counter fits in 32 bits.
+	rfcNonce := nonce
+	if k.draft {
+		rfcNonce = make([]byte, RFCNonceSize)
+		copy(rfcNonce[4:], nonce)
+	}
+
+	counter := uint64(1)
+	if k.counter > 1 {
+		counter = uint64(k.counter)
+	}
+
+	counter += uint64(byteOffset) / 64
+	if counter+(uint64(byteOffset%64)+uint64(len(ciphertext))+63)/64 > 1<<32 {
+		return nil, ErrPlaintextTooLong
+	}
+
+	c, err := xchacha20.NewUnauthenticatedCipher(k.key[:], k.saltNonce(rfcNonce))
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	c.SetCounter(uint32(counter))
+
+	if skip := byteOffset % 64; skip > 0 {
+		var block [64]byte
+		c.XORKeyStream(block[:skip], block[:skip])
+	}
+
+	c.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+func (k *xchacha20Key) OpenAt(dst, nonce, ciphertext, data []byte, byteOffset int) ([]byte, error) {
+	if err := k.checkOpen(nonce); err != nil {
+		return nil, err
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.OpenAt(dst, rfcNonce, ciphertext, data, byteOffset)
+}
@@ -0,0 +1,94 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testOpenAt(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	for i := range nonce {
+		nonce[i] = byte(0x80 + i)
+	}
+
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i * 7)
+	}
+
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	for _, offset := range []int{0, 1, 63, 64, 65, 127, 128, 500, 999, 1000} {
+		actual, err := c.(OpenAtAEAD).OpenAt(nil, nonce, ciphertext, data, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := plaintext[offset:]; !bytes.Equal(expected, actual) {
+			t.Errorf("Bad open at %d: expected %x, was %x", offset, expected, actual)
+		}
+	}
+
+	prefix := []byte("prefix")
+	actual, err := c.(OpenAtAEAD).OpenAt(prefix, nonce, ciphertext, data, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := append(prefix, plaintext[100:]...); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad open: expected %x, was %x", expected, actual)
+	}
+
+	for _, offset := range []int{-1, 1001} {
+		if _, err := c.(OpenAtAEAD).OpenAt(nil, nonce, ciphertext, data, offset); err != ErrInvalidOffset {
+			t.Errorf("Expected invalid offset error for %d but was %v", offset, err)
+		}
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[0] ^= 1
+
+	if _, err := c.(OpenAtAEAD).OpenAt(nil, nonce, tampered, data, 500); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestRFCOpenAt(t *testing.T) {
+	testOpenAt(t, NewRFC)
+}
+
+func TestDraftOpenAt(t *testing.T) {
+	testOpenAt(t, NewDraft)
+}
+
+func TestXOpenAt(t *testing.T) {
+	testOpenAt(t, NewX)
+}
+
+func TestRFCWithCounterOpenAt(t *testing.T) {
+	testOpenAt(t, func(key []byte) (cipher.AEAD, error) {
+		return NewRFCWithCounter(key, 42)
+	})
+}
+
+func TestRFCSaltedOpenAt(t *testing.T) {
+	testOpenAt(t, func(key []byte) (cipher.AEAD, error) {
+		return NewRFCSalted(key, []byte("salt salt sa"))
+	})
+}
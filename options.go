@@ -0,0 +1,154 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrInvalidOptions is returned by NewWithOptions when the given options
+// can't be combined.
+var ErrInvalidOptions = errors.New("invalid combination of options")
+
+// Mode selects the AEAD construction used by NewWithOptions.
+type Mode int
+
+const (
+	// ModeRFC is the RFC7539 construction, as returned by NewRFC.
+	ModeRFC Mode = iota
+
+	// ModeDraft is the draft-agl-tls-chacha20poly1305 construction, as
+	// returned by NewDraft.
+	ModeDraft
+
+	// ModeX is the XChaCha20-Poly1305 construction, as returned by NewX.
+	ModeX
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeRFC:
+		return "RFC"
+	case ModeDraft:
+		return "draft"
+	case ModeX:
+		return "X"
+	default:
+		return "unknown"
+	}
+}
+
+type options struct {
+	mode    Mode
+	counter uint32
+	commit  bool
+	tagLen  int
+}
+
+// Option configures the AEAD returned by NewWithOptions.
+type Option func(*options)
+
+// WithMode selects the AEAD construction. The default is ModeRFC.
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithInitialCounter sets the ChaCha20 block counter that encryption starts
+// from, as NewRFCWithCounter does. It is only supported by ModeRFC.
+func WithInitialCounter(counter uint32) Option {
+	return func(o *options) {
+		o.counter = counter
+	}
+}
+
+// WithKeyCommitment prepends a key commitment to the output of Seal, as
+// NewRFCCommitting does. It is not supported by ModeX.
+func WithKeyCommitment() Option {
+	return func(o *options) {
+		o.commit = true
+	}
+}
+
+// WithTagLength truncates the tag appended by Seal to tagLen bytes, as
+// NewRFCTruncatedTag does. tagLen must be between 8 and 16. It is only
+// supported by ModeRFC and can't be combined with WithKeyCommitment.
+func WithTagLength(tagLen int) Option {
+	return func(o *options) {
+		o.tagLen = tagLen
+	}
+}
+
+// NewWithOptions creates a new AEAD instance using the given key and
+// options. The key must be exactly 256 bits long. With no options, it behaves
+// like NewRFC.
+//
+// ErrInvalidCounter is returned if WithInitialCounter is given zero,
+// ErrInvalidTagSize if WithTagLength is given an unsupported length, and
+// ErrInvalidOptions if the options can't be combined.
+func NewWithOptions(key []byte, opts ...Option) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	o := options{counter: 1, tagLen: poly1305.TagSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.counter == 0 {
+		return nil, ErrInvalidCounter
+	}
+
+	if o.tagLen < minTruncatedTagSize || o.tagLen > poly1305.TagSize {
+		return nil, ErrInvalidTagSize
+	}
+
+	truncated := o.tagLen != poly1305.TagSize
+
+	switch o.mode {
+	case ModeRFC:
+		if truncated && o.commit {
+			return nil, ErrInvalidOptions
+		}
+	case ModeDraft:
+		if o.counter != 1 || truncated {
+			return nil, ErrInvalidOptions
+		}
+	case ModeX:
+		if o.counter != 1 || o.commit || truncated {
+			return nil, ErrInvalidOptions
+		}
+
+		k := new(xchacha20Key)
+		copy(k.key[:], key)
+		return k, nil
+	default:
+		return nil, ErrInvalidOptions
+	}
+
+	var k chacha20Key
+	copy(k.key[:], key)
+
+	k.draft = o.mode == ModeDraft
+
+	if o.counter > 1 {
+		k.counter = o.counter
+	}
+
+	if o.commit {
+		return &committingKey{k: k}, nil
+	}
+
+	if truncated {
+		return &truncatedTagKey{k: k, tagLen: o.tagLen}, nil
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"reflect"
+	"testing"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for _, test := range []struct {
+		name string
+		new  func(key []byte) (cipher.AEAD, error)
+		opts []Option
+	}{
+		{"RFC", NewRFC, nil},
+		{"RFC", NewRFC, []Option{WithMode(ModeRFC)}},
+		{"Draft", NewDraft, []Option{WithMode(ModeDraft)}},
+		{"X", NewX, []Option{WithMode(ModeX)}},
+		{"RFCWithCounter", func(key []byte) (cipher.AEAD, error) {
+			return NewRFCWithCounter(key, 7)
+		}, []Option{WithInitialCounter(7)}},
+		{"RFCCommitting", NewRFCCommitting, []Option{WithKeyCommitment()}},
+		{"RFCTruncatedTag", func(key []byte) (cipher.AEAD, error) {
+			return NewRFCTruncatedTag(key, 12)
+		}, []Option{WithTagLength(12)}},
+		{"RFCTruncatedTag16", NewRFC, []Option{WithTagLength(16)}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			expected, err := test.new(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			actual, err := NewWithOptions(key, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Expected %#v but was %#v", expected, actual)
+			}
+
+			nonce := make([]byte, expected.NonceSize())
+			plaintext := []byte("yay for me")
+
+			if e, a := expected.Seal(nil, nonce, plaintext, nil), actual.Seal(nil, nonce, plaintext, nil); !bytes.Equal(e, a) {
+				t.Errorf("Bad seal: expected %x, was %x", e, a)
+			}
+		})
+	}
+}
+
+func TestNewWithOptionsCombined(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewWithOptions(key, WithInitialCounter(2), WithKeyCommitment())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := bytes.Repeat([]byte{0x42}, 100)
+
+	ciphertext := c.Seal(nil, nonce, plaintext, nil)
+
+	rfc, err := NewRFCWithCounter(key, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := rfc.Seal(nil, nonce, plaintext, nil); !bytes.Equal(expected, ciphertext[commitmentSize:]) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext[commitmentSize:])
+	}
+
+	actual, err := c.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+}
+
+func TestNewWithOptionsInvalid(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	for _, test := range []struct {
+		opts []Option
+		err  error
+	}{
+		{[]Option{WithInitialCounter(0)}, ErrInvalidCounter},
+		{[]Option{WithMode(ModeDraft), WithInitialCounter(2)}, ErrInvalidOptions},
+		{[]Option{WithMode(ModeX), WithInitialCounter(2)}, ErrInvalidOptions},
+		{[]Option{WithMode(ModeX), WithKeyCommitment()}, ErrInvalidOptions},
+		{[]Option{WithMode(Mode(42))}, ErrInvalidOptions},
+		{[]Option{WithTagLength(7)}, ErrInvalidTagSize},
+		{[]Option{WithTagLength(17)}, ErrInvalidTagSize},
+		{[]Option{WithMode(ModeDraft), WithTagLength(12)}, ErrInvalidOptions},
+		{[]Option{WithMode(ModeX), WithTagLength(12)}, ErrInvalidOptions},
+		{[]Option{WithKeyCommitment(), WithTagLength(12)}, ErrInvalidOptions},
+	} {
+		if _, err := NewWithOptions(key, test.opts...); err != test.err {
+			t.Errorf("Expected %v error but was %v", test.err, err)
+		}
+	}
+
+	if _, err := NewWithOptions(key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
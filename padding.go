@@ -0,0 +1,71 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const bucketPrefixLen = 8
+
+// ErrInvalidPadding is returned when an authenticated message does not
+// contain a well formed length prefix.
+var ErrInvalidPadding = errors.New("invalid message padding")
+
+// SealBucketed encrypts and authenticates plaintext after padding it to
+// the next power of two. The length of plaintext is stored as an 8-byte,
+// little-endian prefix and the remainder of the bucket is filled with random
+// bytes. Both the prefix and the padding are encrypted and authenticated, so
+// only the bucket size is revealed.
+//
+// The returned ciphertext must be opened with OpenBucketed.
+func SealBucketed(c cipher.AEAD, nonce, plaintext, data []byte) ([]byte, error) {
+	n := bucketPrefixLen + len(plaintext)
+	size := bucketSize(n)
+
+	buf := make([]byte, size, size+c.Overhead())
+	binary.LittleEndian.PutUint64(buf, uint64(len(plaintext)))
+	copy(buf[bucketPrefixLen:], plaintext)
+
+	if _, err := io.ReadFull(rand.Reader, buf[n:]); err != nil {
+		return nil, err
+	}
+
+	return c.Seal(buf[:0], nonce, buf, data), nil
+}
+
+// OpenBucketed decrypts and authenticates a ciphertext produced by
+// SealBucketed and returns the original, unpadded, plaintext.
+func OpenBucketed(c cipher.AEAD, nonce, ciphertext, data []byte) ([]byte, error) {
+	out, err := c.Open(nil, nonce, ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(out) < bucketPrefixLen {
+		return nil, ErrInvalidPadding
+	}
+
+	n := binary.LittleEndian.Uint64(out)
+	if n > uint64(len(out)-bucketPrefixLen) {
+		return nil, ErrInvalidPadding
+	}
+
+	return out[bucketPrefixLen : bucketPrefixLen+int(n)], nil
+}
+
+// bucketSize returns the smallest power of two that is at least n.
+func bucketSize(n int) int {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+
+	return size
+}
@@ -0,0 +1,83 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBucketed(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, size := range []struct {
+		l, bucket int
+	}{
+		{0, 8},
+		{1, 16},
+		{8, 16},
+		{9, 32},
+		{24, 32},
+		{25, 64},
+		{120, 128},
+		{121, 256},
+		{1016, 1024},
+		{1017, 2048},
+	} {
+		t.Run(fmt.Sprint(size.l), func(t *testing.T) {
+			plaintext := bytes.Repeat([]byte{0x42}, size.l)
+
+			ciphertext, err := SealBucketed(c, nonce, plaintext, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(ciphertext) != size.bucket+c.Overhead() {
+				t.Errorf("Expected ciphertext of %d bytes but was %d", size.bucket+c.Overhead(), len(ciphertext))
+			}
+
+			actual, err := OpenBucketed(c, nonce, ciphertext, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(plaintext, actual) {
+				t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+			}
+		})
+	}
+}
+
+func TestBucketedInvalidPadding(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, plaintext := range [][]byte{
+		[]byte("short"),
+		{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	} {
+		ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+		if _, err := OpenBucketed(c, nonce, ciphertext, data); err != ErrInvalidPadding {
+			t.Errorf("Expected invalid padding error but was %v", err)
+		}
+	}
+}
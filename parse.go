@@ -0,0 +1,21 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "golang.org/x/crypto/poly1305"
+
+// Parse splits a combined nonce || ciphertext || tag blob into its components.
+// The returned slices alias combined. ErrCiphertextTooShort is returned if
+// combined is shorter than nonceSize plus the tag size.
+func Parse(combined []byte, nonceSize int) (nonce, ciphertext, tag []byte, err error) {
+	if nonceSize < 0 || len(combined) < nonceSize+poly1305.TagSize {
+		return nil, nil, nil, ErrCiphertextTooShort
+	}
+
+	tagStart := len(combined) - poly1305.TagSize
+	return combined[:nonceSize:nonceSize],
+		combined[nonceSize:tagStart:tagStart],
+		combined[tagStart:], nil
+}
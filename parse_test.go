@@ -0,0 +1,45 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	vector := rfcTestVectors[0]
+
+	combined := append(append([]byte(nil), vector.nonce...), vector.ciphertext...)
+
+	nonce, ciphertext, tag, err := Parse(combined, len(vector.nonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.nonce, nonce) {
+		t.Errorf("Bad nonce: expected %x, was %x", vector.nonce, nonce)
+	}
+
+	if expected := vector.ciphertext[:len(vector.plaintext)]; !bytes.Equal(expected, ciphertext) {
+		t.Errorf("Bad ciphertext: expected %x, was %x", expected, ciphertext)
+	}
+
+	if expected := vector.ciphertext[len(vector.plaintext):]; !bytes.Equal(expected, tag) {
+		t.Errorf("Bad tag: expected %x, was %x", expected, tag)
+	}
+
+	if _, _, _, err = Parse(combined[:len(vector.nonce)+15], len(vector.nonce)); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+
+	if _, ciphertext, _, err = Parse(combined[:len(vector.nonce)+16], len(vector.nonce)); err != nil || len(ciphertext) != 0 {
+		t.Errorf("Expected empty ciphertext but was %x (%v)", ciphertext, err)
+	}
+
+	if _, _, _, err = Parse(combined, -1); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// SealOrError behaves like c.Seal but returns ErrInvalidNonce, rather than
+// panicking, if nonce is the wrong size. If c implements SafeSealAEAD, any
+// other error that Seal would panic with is returned too.
+func SealOrError(c cipher.AEAD, dst, nonce, plaintext, data []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() {
+		return nil, ErrInvalidNonce
+	}
+
+	if sc, ok := c.(SafeSealAEAD); ok {
+		return sc.SealSafe(dst, nonce, plaintext, data)
+	}
+
+	return c.Seal(dst, nonce, plaintext, data), nil
+}
+
+// OpenOrPanic behaves like c.Open but panics with the error, rather than
+// returning it, if ciphertext can't be opened. It is intended for opening
+// messages whose authenticity is an invariant of the program, such as those
+// embedded at build time, where a failure can only be a bug.
+func OpenOrPanic(c cipher.AEAD, dst, nonce, ciphertext, data []byte) []byte {
+	plaintext, err := c.Open(dst, nonce, ciphertext, data)
+	if err != nil {
+		panic(err)
+	}
+
+	return plaintext
+}
@@ -0,0 +1,102 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSealOrError(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	actual, err := SealOrError(c, nil, nonce, plaintext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+	}
+
+	if actual, err = SealOrError(c, nil, nonce[1:], plaintext, data); err != ErrInvalidNonce || actual != nil {
+		t.Errorf("Expected invalid nonce error but was %v (%x)", err, actual)
+	}
+}
+
+func TestRFCSealOrError(t *testing.T) {
+	testSealOrError(t, NewRFC)
+}
+
+func TestDraftSealOrError(t *testing.T) {
+	testSealOrError(t, NewDraft)
+}
+
+func TestXSealOrError(t *testing.T) {
+	testSealOrError(t, NewX)
+}
+
+func TestRFCDualTagSealOrError(t *testing.T) {
+	testSealOrError(t, NewRFCDualTag)
+}
+
+func testOpenOrPanic(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	if actual := OpenOrPanic(c, nil, nonce, ciphertext, data); !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	for _, test := range []struct {
+		nonce, ciphertext []byte
+		err               error
+	}{
+		{nonce, ciphertext[1:], ErrAuthFailed},
+		{nonce, ciphertext[:TagSize-1], ErrCiphertextTooShort},
+		{nonce[1:], ciphertext, ErrInvalidNonce},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != test.err {
+					t.Errorf("Expected %v panic but was %v", test.err, r)
+				}
+			}()
+
+			OpenOrPanic(c, nil, test.nonce, test.ciphertext, data)
+		}()
+	}
+}
+
+func TestRFCOpenOrPanic(t *testing.T) {
+	testOpenOrPanic(t, NewRFC)
+}
+
+func TestDraftOpenOrPanic(t *testing.T) {
+	testOpenOrPanic(t, NewDraft)
+}
+
+func TestXOpenOrPanic(t *testing.T) {
+	testOpenOrPanic(t, NewX)
+}
@@ -0,0 +1,108 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// PolyKeyAEAD is implemented by the AEADs returned from NewRFC and NewDraft.
+// It allows the one-time Poly1305 key to be derived externally, for instance
+// by a hardware security module, while the payload is still encrypted in Go.
+//
+// The supplied Poly1305 key must be the first 32 bytes of the ChaCha20
+// counter-0 block for the given key and nonce. It must never be reused
+// across nonces; doing so allows tags to be forged.
+type PolyKeyAEAD interface {
+	cipher.AEAD
+
+	// SealWithPolyKey behaves like Seal but authenticates with the given
+	// one-time Poly1305 key rather than deriving it.
+	SealWithPolyKey(polyKey [32]byte, dst, nonce, plaintext, data []byte) []byte
+
+	// OpenWithPolyKey behaves like Open but authenticates with the given
+	// one-time Poly1305 key rather than deriving it.
+	OpenWithPolyKey(polyKey [32]byte, dst, nonce, ciphertext, data []byte) ([]byte, error)
+}
+
+// skipKeyBlock returns a ChaCha20 cipher for nonce, positioned at the start
+// of the counter-1 block. The underlying cipher cannot seek, so the counter-0
+// block is generated and discarded.
+func (k *chacha20Key) skipKeyBlock(nonce []byte) cipher.Stream {
+	c := k.stream(nonce)
+
+	var block [64]byte
+	c.XORKeyStream(block[:], block[:])
+
+	return c
+}
+
+func (k *chacha20Key) SealWithPolyKey(polyKey [32]byte, dst, nonce, plaintext, data []byte) []byte {
+	if err := k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	return k.seal(k.skipKeyBlock(nonce), polyKey[:], dst, plaintext, data, nil)
+}
+
+func (k *chacha20Key) OpenWithPolyKey(polyKey [32]byte, dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	return k.open(k.skipKeyBlock(nonce), polyKey[:], dst, ciphertext, tag, data)
+}
+
+// Poly1305KeyAEAD is implemented by the AEADs returned from NewRFC, NewDraft
+// and NewX. It exposes the one-time Poly1305 key for debugging
+// interoperability with other implementations.
+type Poly1305KeyAEAD interface {
+	cipher.AEAD
+
+	// Poly1305Key returns the one-time Poly1305 key that Seal and Open
+	// use for nonce: the first 32 bytes of the ChaCha20 counter-0 block.
+	// ErrInvalidNonce is returned if nonce is the wrong size.
+	//
+	// The key allows tags to be forged for nonce and must never be
+	// logged or otherwise exposed in production.
+	Poly1305Key(nonce []byte) ([32]byte, error)
+}
+
+func (k *chacha20Key) Poly1305Key(nonce []byte) ([32]byte, error) {
+	var polyKey [32]byte
+
+	if len(nonce) != k.NonceSize() {
+		return polyKey, ErrInvalidNonce
+	}
+
+	if k.destroyed {
+		return polyKey, ErrInvalidKey
+	}
+
+	pk := k.keyBlock(k.stream(nonce))
+	copy(polyKey[:], pk[:32])
+	k.putKeyBlock(pk)
+	return polyKey, nil
+}
+
+func (k *xchacha20Key) Poly1305Key(nonce []byte) ([32]byte, error) {
+	if len(nonce) != XNonceSize {
+		return [32]byte{}, ErrInvalidNonce
+	}
+
+	if k.destroyed {
+		return [32]byte{}, ErrInvalidKey
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.Poly1305Key(rfcNonce)
+}
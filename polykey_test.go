@@ -0,0 +1,157 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/tmthrgd/chacha20"
+)
+
+func testPolyKey(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), vector testVector) {
+	c, err := newChaCha20Poly1305(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := chacha20.New(vector.key, vector.nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var block [64]byte
+	s.XORKeyStream(block[:], block[:])
+
+	var polyKey [32]byte
+	copy(polyKey[:], block[:])
+
+	pc := c.(PolyKeyAEAD)
+
+	actual := pc.SealWithPolyKey(polyKey, nil, vector.nonce, vector.plaintext, vector.data)
+	if !bytes.Equal(vector.ciphertext, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", vector.ciphertext, actual)
+	}
+
+	plaintext, err := pc.OpenWithPolyKey(polyKey, nil, vector.nonce, vector.ciphertext, vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, plaintext) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, plaintext)
+	}
+
+	polyKey[0] ^= 1
+
+	if _, err = pc.OpenWithPolyKey(polyKey, nil, vector.nonce, vector.ciphertext, vector.data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestRFCPolyKey(t *testing.T) {
+	testPolyKey(t, NewRFC, rfcTestVectors[0])
+}
+
+func TestDraftPolyKey(t *testing.T) {
+	testPolyKey(t, NewDraft, draftTestVectors[0])
+}
+
+func TestRFCPoly1305Key(t *testing.T) {
+	key := mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pc := c.(Poly1305KeyAEAD)
+
+	for _, test := range []struct {
+		nonce, polyKey string
+	}{
+		// https://tools.ietf.org/html/rfc7539#section-2.6.2
+		{"000000000001020304050607", "8ad5a08b905f81cc815040274ab29471a833b637e3fd0da508dbb8e2fdd1a646"},
+		// https://tools.ietf.org/html/rfc7539#section-2.8.2
+		{"070000004041424344454647", "7bac2b252db447af09b67a55a4e955840ae1d6731075d9eb2a9375783ed553ff"},
+	} {
+		polyKey, err := pc.Poly1305Key(mustHexDecode(test.nonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := mustHexDecode(test.polyKey); !bytes.Equal(expected, polyKey[:]) {
+			t.Errorf("Bad Poly1305 key: expected %x, was %x", expected, polyKey)
+		}
+	}
+
+	if _, err = pc.Poly1305Key(make([]byte, DraftNonceSize)); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestDraftPoly1305Key(t *testing.T) {
+	vector := draftTestVectors[0]
+
+	c, err := NewDraft(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polyKey, err := c.(Poly1305KeyAEAD).Poly1305Key(vector.nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ChaCha20Block0(vector.key, vector.nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(block[:32], polyKey[:]) {
+		t.Errorf("Bad Poly1305 key: expected %x, was %x", block[:32], polyKey)
+	}
+}
+
+func TestXPoly1305Key(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	nonce := make([]byte, XNonceSize)
+	for i := range nonce {
+		nonce[i] = byte(0x40 + i)
+	}
+
+	c, err := NewX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polyKey, err := c.(Poly1305KeyAEAD).Poly1305Key(nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subkey, err := HChaCha20(key, nonce[:16])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ChaCha20Block0(subkey, append(make([]byte, 4), nonce[16:]...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(block[:32], polyKey[:]) {
+		t.Errorf("Bad Poly1305 key: expected %x, was %x", block[:32], polyKey)
+	}
+
+	if _, err = c.(Poly1305KeyAEAD).Poly1305Key(nonce[:RFCNonceSize]); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+func TestNewChaCha20(t *testing.T) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for _, nonceSize := range []int{DraftNonceSize, RFCNonceSize} {
+		nonce := make([]byte, nonceSize)
+		for i := range nonce {
+			nonce[i] = byte(0x80 + i)
+		}
+
+		c, err := newChaCha20(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rfcNonce := make([]byte, RFCNonceSize)
+		copy(rfcNonce[RFCNonceSize-nonceSize:], nonce)
+
+		ref, err := xchacha20.NewUnauthenticatedCipher(key, rfcNonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Feed odd-sized chunks to cross block boundaries.
+		for _, n := range []int{1, 63, 64, 65, 1000} {
+			actual := make([]byte, n)
+			c.XORKeyStream(actual, actual)
+
+			expected := make([]byte, n)
+			ref.XORKeyStream(expected, expected)
+
+			if !bytes.Equal(expected, actual) {
+				t.Errorf("Bad keystream for %d byte nonce: expected %x, was %x", nonceSize, expected, actual)
+			}
+		}
+	}
+}
+
+func TestPureGo(t *testing.T) {
+	if !pureGo {
+		t.Skip("built without the purego tag")
+	}
+
+	c, err := newChaCha20(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.(*xchacha20.Cipher); !ok {
+		t.Errorf("Expected golang.org/x/crypto/chacha20 cipher but was %T", c)
+	}
+}
+
+func TestNotPureGo(t *testing.T) {
+	if pureGo {
+		t.Skip("built with the purego tag")
+	}
+
+	c, err := newChaCha20(make([]byte, KeySize), make([]byte, RFCNonceSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.(*xchacha20.Cipher); ok {
+		t.Error("Expected github.com/tmthrgd/chacha20 cipher but was golang.org/x/crypto/chacha20")
+	}
+}
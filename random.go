@@ -0,0 +1,39 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+)
+
+// SealWithRandomNonce seals plaintext with c using a nonce read from
+// crypto/rand, and appends the nonce followed by the ciphertext to dst.
+//
+// Random nonces are only safe for a limited number of messages per key: for
+// the 12-byte RFC7539 nonce, no more than 2^32 messages should be sealed, and
+// the 8-byte draft nonce should not be used at all. NewX is recommended.
+func SealWithRandomNonce(c cipher.AEAD, dst, plaintext, data []byte) ([]byte, error) {
+	ret, out := sliceForAppend(dst, c.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return nil, err
+	}
+
+	return c.Seal(ret, out, plaintext, data), nil
+}
+
+// OpenPrefixedNonce opens a ciphertext produced by SealWithRandomNonce,
+// splitting the nonce from the front of ciphertext, and appends the plaintext
+// to dst. ErrCiphertextTooShort is returned if ciphertext is shorter than the
+// nonce.
+func OpenPrefixedNonce(c cipher.AEAD, dst, ciphertext, data []byte) ([]byte, error) {
+	if len(ciphertext) < c.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := ciphertext[:c.NonceSize()], ciphertext[c.NonceSize():]
+	return c.Open(dst, nonce, ciphertext, data)
+}
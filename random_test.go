@@ -0,0 +1,70 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSealWithRandomNonce(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	a, err := SealWithRandomNonce(c, nil, plaintext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := SealWithRandomNonce(c, nil, plaintext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(a) != c.NonceSize()+len(plaintext)+c.Overhead() {
+		t.Errorf("Expected %d bytes but was %d", c.NonceSize()+len(plaintext)+c.Overhead(), len(a))
+	}
+
+	if bytes.Equal(a, b) {
+		t.Errorf("Expected two seals of the same plaintext to differ, both were %x", a)
+	}
+
+	for _, ciphertext := range [][]byte{a, b} {
+		actual, err := OpenPrefixedNonce(c, nil, ciphertext, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+		}
+	}
+
+	a[len(a)-1] ^= 1
+
+	if _, err := OpenPrefixedNonce(c, nil, a, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err := OpenPrefixedNonce(c, nil, a[:c.NonceSize()-1], data); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+}
+
+func TestRFCSealWithRandomNonce(t *testing.T) {
+	testSealWithRandomNonce(t, NewRFC)
+}
+
+func TestXSealWithRandomNonce(t *testing.T) {
+	testSealWithRandomNonce(t, NewX)
+}
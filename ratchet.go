@@ -0,0 +1,105 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import xchacha20 "golang.org/x/crypto/chacha20"
+
+// ratchetLabel is the HChaCha20 nonce used to derive the next ratchet key.
+var ratchetLabel = []byte("poly1305-ratchet")
+
+// ratchet holds the current key of a ratchet. Each message is sealed under
+// its own key, with an all-zero RFC7539 nonce, after which the next key is
+// derived as HChaCha20(key, "poly1305-ratchet") and the old key is zeroed.
+type ratchet struct {
+	k chacha20Key
+}
+
+func newRatchet(key []byte) (*ratchet, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	r := new(ratchet)
+	copy(r.k.key[:], key)
+	return r, nil
+}
+
+func (r *ratchet) step() {
+	next, err := xchacha20.HChaCha20(r.k.key[:], ratchetLabel)
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	copy(r.k.key[:], next)
+	wipe(next)
+}
+
+// RatchetSealer seals a sequence of messages, ratcheting the key forward
+// after each one so that compromise of the current key does not expose past
+// messages. It must be paired with a RatchetOpener created with the same
+// initial key.
+//
+// The initial key must not be used for anything else. In particular, each
+// ratchet step is the subkey NewX derives for a nonce beginning with
+// "poly1305-ratchet", so the key must never be used with NewX.
+//
+// A RatchetSealer is not safe for concurrent use.
+type RatchetSealer struct {
+	r *ratchet
+}
+
+// NewRatchetSealer creates a new RatchetSealer using the given initial key.
+// The key must be exactly 256 bits long.
+func NewRatchetSealer(key []byte) (*RatchetSealer, error) {
+	r, err := newRatchet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RatchetSealer{r}, nil
+}
+
+// Seal encrypts and authenticates plaintext under the current key, appends
+// the result to dst and then ratchets the key forward.
+func (s *RatchetSealer) Seal(dst, plaintext, data []byte) []byte {
+	var nonce [RFCNonceSize]byte
+	ret := s.r.k.Seal(dst, nonce[:], plaintext, data)
+
+	s.r.step()
+	return ret
+}
+
+// RatchetOpener opens a sequence of messages sealed by a RatchetSealer.
+//
+// A RatchetOpener is not safe for concurrent use.
+type RatchetOpener struct {
+	r *ratchet
+}
+
+// NewRatchetOpener creates a new RatchetOpener using the given initial key.
+// The key must be exactly 256 bits long.
+func NewRatchetOpener(key []byte) (*RatchetOpener, error) {
+	r, err := newRatchet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RatchetOpener{r}, nil
+}
+
+// Open authenticates and decrypts ciphertext under the current key and
+// appends the result to dst. The key is only ratcheted forward if
+// authentication succeeds, so a forged message does not desynchronize the
+// opener from the sealer.
+func (o *RatchetOpener) Open(dst, ciphertext, data []byte) ([]byte, error) {
+	var nonce [RFCNonceSize]byte
+	ret, err := o.r.k.Open(dst, nonce[:], ciphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	o.r.step()
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+)
+
+func TestRatchet(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	s, err := NewRatchetSealer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := NewRatchetOpener(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("whoah yeah")
+
+	var ciphertexts [][]byte
+	for i := 0; i < 4; i++ {
+		ciphertexts = append(ciphertexts, s.Seal(nil, []byte(fmt.Sprintf("message %d", i)), data))
+	}
+
+	if bytes.Equal(ciphertexts[0], ciphertexts[1]) {
+		t.Error("Ratchet did not change the key")
+	}
+
+	// A future message can't be opened with the current key.
+	if _, err = o.Open(nil, ciphertexts[1], data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		actual, err := o.Open(nil, ciphertexts[i], data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []byte(fmt.Sprintf("message %d", i)); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad open: expected %x, was %x", expected, actual)
+		}
+	}
+
+	// A past message can't be opened with the current key.
+	if _, err = o.Open(nil, ciphertexts[0], data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = o.Open(nil, ciphertexts[2], data); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = NewRatchetSealer(key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
+
+func TestRatchetStep(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	s, err := NewRatchetSealer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	s.Seal(nil, plaintext, data)
+	actual := s.Seal(nil, plaintext, data)
+
+	next, err := xchacha20.HChaCha20(key, []byte("poly1305-ratchet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewRFC(next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := c.Seal(nil, make([]byte, RFCNonceSize), plaintext, data); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+const sealReaderBufSize = 16 * 1024
+
+// maxEmptyReads is the number of consecutive empty reads SealReader allows
+// while checking for trailing data before giving up with io.ErrNoProgress, as
+// bufio does.
+const maxEmptyReads = 100
+
+// ErrLengthMismatch is returned by SealReader when the reader does not yield
+// exactly the declared number of bytes.
+var ErrLengthMismatch = errors.New("reader length mismatch")
+
+// ReaderAEAD is implemented by the AEADs returned from NewRFC and NewDraft. It
+// allows a message of known length to be sealed from an io.Reader with bounded
+// memory.
+type ReaderAEAD interface {
+	cipher.AEAD
+
+	// SealReader reads exactly length bytes from src, encrypting and
+	// authenticating them, and writes the ciphertext followed by the tag
+	// to dst. The output is byte-for-byte identical to Seal.
+	//
+	// If length is negative, or src yields fewer or more than length
+	// bytes, ErrLengthMismatch is returned. Ciphertext may already have
+	// been written to dst when an error is returned, but the tag will not
+	// have been.
+	SealReader(dst io.Writer, nonce []byte, src io.Reader, length int, data []byte) error
+}
+
+func (k *chacha20Key) SealReader(dst io.Writer, nonce []byte, src io.Reader, length int, data []byte) error {
+	if len(nonce) != k.NonceSize() {
+		panic(ErrInvalidNonce)
+	}
+
+	if length < 0 {
+		return ErrLengthMismatch
+	}
+
+	if err := k.checkSeal(nonce, length, uint64(len(data))); err != nil {
+		return err
+	}
+
+	c := k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	mac := k.newMACWriter(pk[:32])
+	mac.writeData(data)
+	mac.endData()
+
+	buf := make([]byte, sealReaderBufSize)
+
+	for length > 0 {
+		chunk := buf
+		if length < len(chunk) {
+			chunk = chunk[:length]
+		}
+
+		if _, err := io.ReadFull(src, chunk); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return ErrLengthMismatch
+			}
+
+			return err
+		}
+
+		c.XORKeyStream(chunk, chunk)
+		mac.Write(chunk)
+
+		if _, err := dst.Write(chunk); err != nil {
+			return err
+		}
+
+		length -= len(chunk)
+	}
+
+	if err := checkDrained(src, buf[:1]); err != nil {
+		return err
+	}
+
+	var tag [poly1305.TagSize]byte
+	mac.sum(tag[:])
+
+	_, err := dst.Write(tag[:])
+	return err
+}
+
+// checkDrained returns nil if src is at EOF, ErrLengthMismatch if it yields
+// more data and otherwise the error from src. A Read that returns no data and
+// a nil error is not EOF, so src is read again until it returns either.
+func checkDrained(src io.Reader, buf []byte) error {
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := src.Read(buf)
+		switch {
+		case n != 0:
+			return ErrLengthMismatch
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+
+	return io.ErrNoProgress
+}
+
+// SealAsReader seals plaintext with c and returns a reader over the nonce
+// followed by the ciphertext and tag, suitable for use as a request body. The
+// message is sealed eagerly.
+func SealAsReader(c cipher.AEAD, nonce, plaintext, data []byte) io.Reader {
+	out := make([]byte, len(nonce), len(nonce)+len(plaintext)+c.Overhead())
+	copy(out, nonce)
+	return bytes.NewReader(c.Seal(out, nonce, plaintext, data))
+}
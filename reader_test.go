@@ -0,0 +1,125 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// stutterReader returns no data and a nil error from every other Read.
+type stutterReader struct {
+	r       io.Reader
+	stutter bool
+}
+
+func (s *stutterReader) Read(p []byte) (int, error) {
+	if s.stutter = !s.stutter; s.stutter {
+		return 0, nil
+	}
+
+	return s.r.Read(p)
+}
+
+// emptyReader returns no data and a nil error from every Read.
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) {
+	return 0, nil
+}
+
+func testSealReader(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc := c.(ReaderAEAD)
+
+	nonce := make([]byte, c.NonceSize())
+	data := []byte("whoah yeah")
+
+	for _, l := range []int{0, 1, 15, 16, 17, 64, sealReaderBufSize - 1, sealReaderBufSize, 3*sealReaderBufSize + 7} {
+		t.Run(fmt.Sprint(l), func(t *testing.T) {
+			plaintext := make([]byte, l)
+			for i := range plaintext {
+				plaintext[i] = byte(i)
+			}
+
+			var buf bytes.Buffer
+			if err := rc.SealReader(&buf, nonce, bytes.NewReader(plaintext), l, data); err != nil {
+				t.Fatal(err)
+			}
+
+			if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, buf.Bytes()) {
+				t.Errorf("Bad seal: expected %x, was %x", expected, buf.Bytes())
+			}
+
+			buf.Reset()
+
+			if err := rc.SealReader(&buf, nonce, bytes.NewReader(plaintext), l+1, data); err != ErrLengthMismatch {
+				t.Errorf("Expected length mismatch error for short reader but was %v", err)
+			}
+
+			if err := rc.SealReader(&buf, nonce, bytes.NewReader(append(plaintext, 0)), l, data); err != ErrLengthMismatch {
+				t.Errorf("Expected length mismatch error for long reader but was %v", err)
+			}
+
+			buf.Reset()
+
+			if err := rc.SealReader(&buf, nonce, &stutterReader{r: bytes.NewReader(plaintext)}, l, data); err != nil {
+				t.Errorf("Expected no error for stuttering reader but was %v", err)
+			}
+
+			if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, buf.Bytes()) {
+				t.Errorf("Bad seal: expected %x, was %x", expected, buf.Bytes())
+			}
+
+			if err := rc.SealReader(&buf, nonce, &stutterReader{r: bytes.NewReader(append(plaintext, 0))}, l, data); err != ErrLengthMismatch {
+				t.Errorf("Expected length mismatch error for long stuttering reader but was %v", err)
+			}
+		})
+	}
+
+	if err := rc.SealReader(ioutil.Discard, nonce, bytes.NewReader(nil), -1, data); err != ErrLengthMismatch {
+		t.Errorf("Expected length mismatch error for negative length but was %v", err)
+	}
+
+	if err := rc.SealReader(ioutil.Discard, nonce, emptyReader{}, 0, data); err != io.ErrNoProgress {
+		t.Errorf("Expected no progress error but was %v", err)
+	}
+}
+
+func TestRFCSealReader(t *testing.T) {
+	testSealReader(t, NewRFC)
+}
+
+func TestDraftSealReader(t *testing.T) {
+	testSealReader(t, NewDraft)
+}
+
+func TestSealAsReader(t *testing.T) {
+	vector := rfcTestVectors[0]
+
+	c, err := NewRFC(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := ioutil.ReadAll(SealAsReader(c, vector.nonce, vector.plaintext, vector.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := append(append([]byte(nil), vector.nonce...), vector.ciphertext...); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+	}
+}
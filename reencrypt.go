@@ -0,0 +1,151 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// ReEncrypt decrypts oldCiphertext under oldKey and re-encrypts it under
+// newKey using the RFC7539 construction. A fresh random nonce is generated for
+// the new ciphertext and is prepended to it. The nonce is typically loaded
+// from storage, so ErrInvalidNonce is returned, rather than panicked, if it
+// is the wrong size.
+//
+// The intermediate plaintext never leaves the package and is zeroed before
+// ReEncrypt returns.
+func ReEncrypt(oldKey, newKey, nonce, oldCiphertext, data []byte) ([]byte, error) {
+	if len(nonce) != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	oc, err := NewRFC(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nc, err := NewRFC(newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := oc.Open(nil, nonce, oldCiphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipe(plaintext)
+
+	out := make([]byte, nc.NonceSize(), nc.NonceSize()+len(plaintext)+nc.Overhead())
+	if _, err := io.ReadFull(rand.Reader, out); err != nil {
+		return nil, err
+	}
+
+	return nc.Seal(out, out, plaintext, data), nil
+}
+
+// Entry is a single sealed message in a batch passed to ReEncryptBatch.
+type Entry struct {
+	Nonce      []byte
+	Ciphertext []byte
+	Data       []byte // additional data, may be nil
+}
+
+// ReEncryptBatch decrypts each of entries under oldKey and re-encrypts it
+// under newKey using the RFC7539 construction, with a fresh random nonce for
+// each entry. Each entry's additional data is carried over unchanged. If any
+// entry fails to open, no entries are returned. Entry nonces are typically
+// loaded from storage, so ErrInvalidNonce is returned, rather than panicked,
+// if one is the wrong size.
+//
+// Scratch space for the intermediate plaintexts is reused across the batch
+// and is zeroed before ReEncryptBatch returns.
+func ReEncryptBatch(oldKey, newKey []byte, entries []Entry) ([]Entry, error) {
+	oc, err := NewRFC(oldKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nc, err := NewRFC(newKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces := make([]byte, len(entries)*nc.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonces); err != nil {
+		return nil, err
+	}
+
+	var scratch []byte
+	defer func() { wipe(scratch) }()
+
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		if len(e.Nonce) != oc.NonceSize() {
+			return nil, ErrInvalidNonce
+		}
+
+		plaintext, err := oc.Open(scratch[:0], e.Nonce, e.Ciphertext, e.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		if cap(plaintext) > cap(scratch) {
+			wipe(scratch)
+			scratch = plaintext[:cap(plaintext)]
+		}
+
+		nonce := nonces[i*nc.NonceSize() : (i+1)*nc.NonceSize() : (i+1)*nc.NonceSize()]
+		out[i] = Entry{
+			Nonce:      nonce,
+			Ciphertext: nc.Seal(nil, nonce, plaintext, e.Data),
+			Data:       e.Data,
+		}
+
+		wipe(plaintext)
+	}
+
+	return out, nil
+}
+
+// Convert decrypts draftCiphertext using the draft construction and
+// re-encrypts it using the RFC7539 construction with rfcNonce. Both
+// constructions use the same key. It returns ErrInvalidNonce if either nonce
+// is the wrong size.
+//
+// The intermediate plaintext never leaves the package and is zeroed before
+// Convert returns.
+func Convert(key, draftNonce, draftCiphertext, data, rfcNonce []byte) (rfcCiphertext []byte, err error) {
+	if len(draftNonce) != DraftNonceSize || len(rfcNonce) != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	dc, err := NewDraft(key)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := NewRFC(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := dc.Open(nil, draftNonce, draftCiphertext, data)
+	if err != nil {
+		return nil, err
+	}
+
+	defer wipe(plaintext)
+
+	return rc.Seal(nil, rfcNonce, plaintext, data), nil
+}
+
+// wipe overwrites b with zeros.
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
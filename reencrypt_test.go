@@ -0,0 +1,194 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReEncrypt(t *testing.T) {
+	oldKey := make([]byte, KeySize)
+	newKey := bytes.Repeat([]byte{1}, KeySize)
+
+	oc, err := NewRFC(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nc, err := NewRFC(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, oc.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := oc.Seal(nil, nonce, plaintext, data)
+
+	out, err := ReEncrypt(oldKey, newKey, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := nc.Open(nil, out[:nc.NonceSize()], out[nc.NonceSize():], data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	if _, err = oc.Open(nil, out[:oc.NonceSize()], out[oc.NonceSize():], data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	ciphertext[0] ^= 1
+
+	if _, err = ReEncrypt(oldKey, newKey, nonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = ReEncrypt(oldKey, newKey[:31], nonce, ciphertext, data); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	for _, size := range []int{0, DraftNonceSize, XNonceSize} {
+		if _, err = ReEncrypt(oldKey, newKey, make([]byte, size), ciphertext, data); err != ErrInvalidNonce {
+			t.Errorf("Expected invalid nonce error for %d-byte nonce but was %v", size, err)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	dc, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	draftNonce := make([]byte, dc.NonceSize())
+	rfcNonce := bytes.Repeat([]byte{1}, rc.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	draftCiphertext := dc.Seal(nil, draftNonce, plaintext, data)
+
+	rfcCiphertext, err := Convert(key, draftNonce, draftCiphertext, data, rfcNonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := rc.Seal(nil, rfcNonce, plaintext, data); !bytes.Equal(expected, rfcCiphertext) {
+		t.Errorf("Bad convert: expected %x, was %x", expected, rfcCiphertext)
+	}
+
+	actual, err := rc.Open(nil, rfcNonce, rfcCiphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	draftCiphertext[0] ^= 1
+
+	if _, err = Convert(key, draftNonce, draftCiphertext, data, rfcNonce); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = Convert(key, rfcNonce, draftCiphertext, data, rfcNonce); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+
+	if _, err = Convert(key, draftNonce, draftCiphertext, data, draftNonce); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestReEncryptBatch(t *testing.T) {
+	oldKey := make([]byte, KeySize)
+	newKey := bytes.Repeat([]byte{1}, KeySize)
+
+	oc, err := NewRFC(oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nc, err := NewRFC(newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintexts := [][]byte{
+		[]byte("yay for me"),
+		{},
+		bytes.Repeat([]byte("a much longer entry "), 20),
+		[]byte("short"),
+	}
+
+	entries := make([]Entry, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		nonce := make([]byte, oc.NonceSize())
+		nonce[0] = byte(i)
+
+		data := []byte{byte(i)}
+		entries[i] = Entry{
+			Nonce:      nonce,
+			Ciphertext: oc.Seal(nil, nonce, plaintext, data),
+			Data:       data,
+		}
+	}
+
+	out, err := ReEncryptBatch(oldKey, newKey, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != len(entries) {
+		t.Fatalf("Expected %d entries but was %d", len(entries), len(out))
+	}
+
+	for i, e := range out {
+		actual, err := nc.Open(nil, e.Nonce, e.Ciphertext, e.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintexts[i], actual) {
+			t.Errorf("Bad open: expected %x, was %x", plaintexts[i], actual)
+		}
+
+		if _, err = oc.Open(nil, e.Nonce, e.Ciphertext, e.Data); err != ErrAuthFailed {
+			t.Errorf("Expected message authentication failed error but was %v", err)
+		}
+	}
+
+	nonce := entries[1].Nonce
+	entries[1].Nonce = nonce[:len(nonce)-1]
+
+	if _, err = ReEncryptBatch(oldKey, newKey, entries); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+
+	entries[1].Nonce = nonce
+	entries[2].Ciphertext[0] ^= 1
+
+	if _, err = ReEncryptBatch(oldKey, newKey, entries); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, err = ReEncryptBatch(oldKey, newKey[:31], entries); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// SafeSealAEAD is implemented by the AEADs returned from NewRFC, NewDraft and
+// NewX. It allows a message to be sealed with a nonce from an untrusted
+// source without risking a panic.
+type SafeSealAEAD interface {
+	cipher.AEAD
+
+	// SealSafe behaves like Seal but returns ErrInvalidNonce, rather than
+	// panicking, if nonce is the wrong size. Likewise, it returns any other
+	// error that Seal would panic with because of the size of its inputs
+	// or the state of the key.
+	SealSafe(dst, nonce, plaintext, data []byte) ([]byte, error)
+}
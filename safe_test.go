@@ -0,0 +1,61 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSealSafe(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	nonce := make([]byte, c.NonceSize())
+
+	actual, err := c.(SafeSealAEAD).SealSafe(nil, nonce, plaintext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Expected no panic but was %v", r)
+		}
+	}()
+
+	for _, size := range []int{0, 1, 8, 12, 16, 24, 32} {
+		if size == c.NonceSize() {
+			continue
+		}
+
+		if _, err := c.(SafeSealAEAD).SealSafe(nil, make([]byte, size), plaintext, data); err != ErrInvalidNonce {
+			t.Errorf("Expected invalid nonce error for %d byte nonce but was %v", size, err)
+		}
+	}
+}
+
+func TestRFCSealSafe(t *testing.T) {
+	testSealSafe(t, NewRFC)
+}
+
+func TestDraftSealSafe(t *testing.T) {
+	testSealSafe(t, NewDraft)
+}
+
+func TestXSealSafe(t *testing.T) {
+	testSealSafe(t, NewX)
+}
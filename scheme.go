@@ -0,0 +1,122 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+const schemePrefixLen = 4
+
+// ErrInvalidPrefix is returned by NewSequenced when the base nonce prefix is
+// not 4 bytes long, the 12-byte nonce less the 8-byte counter.
+var ErrInvalidPrefix = errors.New("invalid nonce prefix size")
+
+// NonceScheme generates unique RFC7539 nonces for a single key. Each nonce is
+// a 32-bit random prefix followed by a 64-bit, little-endian counter. The
+// counter is incremented for each nonce and, when it would wrap, the prefix is
+// re-randomized and the counter restarted from zero.
+//
+// A NonceScheme is safe for concurrent use.
+type NonceScheme struct {
+	aead cipher.AEAD
+
+	rand io.Reader // nil if the prefix is fixed
+
+	mu      sync.Mutex
+	prefix  [schemePrefixLen]byte
+	counter uint64
+}
+
+// NewScheme returns a NonceScheme that seals with c. c must use 12-byte
+// RFC7539 nonces, otherwise ErrInvalidNonce is returned.
+func NewScheme(c cipher.AEAD) (*NonceScheme, error) {
+	if c.NonceSize() != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	s := &NonceScheme{
+		aead: c,
+		rand: rand.Reader,
+	}
+
+	if err := s.refreshPrefix(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// NewSequenced returns a NonceScheme that seals with c using a fixed 4-byte
+// prefix rather than a random one. Its nonces are the prefix followed by the
+// 64-bit, little-endian counter, starting from zero. As the prefix can't be
+// refreshed, Next returns ErrNoncesExhausted once the counter is exhausted.
+//
+// The nonce and prefix sizes are validated here, rather than on first use, so
+// that misconfiguration fails at startup.
+func NewSequenced(c cipher.AEAD, prefix []byte) (*NonceScheme, error) {
+	if c.NonceSize() != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if len(prefix) != schemePrefixLen {
+		return nil, ErrInvalidPrefix
+	}
+
+	s := &NonceScheme{aead: c}
+	copy(s.prefix[:], prefix)
+	return s, nil
+}
+
+func (s *NonceScheme) refreshPrefix() error {
+	if s.rand == nil {
+		return ErrNoncesExhausted
+	}
+
+	var prefix [schemePrefixLen]byte
+	if _, err := io.ReadFull(s.rand, prefix[:]); err != nil {
+		return err
+	}
+
+	s.prefix, s.counter = prefix, 0
+	return nil
+}
+
+// Next returns the next nonce in the scheme.
+func (s *NonceScheme) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counter == math.MaxUint64 {
+		if err := s.refreshPrefix(); err != nil {
+			return nil, err
+		}
+	}
+
+	nonce := make([]byte, RFCNonceSize)
+	copy(nonce, s.prefix[:])
+	binary.LittleEndian.PutUint64(nonce[schemePrefixLen:], s.counter)
+
+	s.counter++
+	return nonce, nil
+}
+
+// SealScheme seals plaintext with the next nonce in the scheme, appending the
+// ciphertext to dst. The nonce used is returned and must be conveyed to the
+// recipient.
+func (s *NonceScheme) SealScheme(dst, plaintext, data []byte) (nonce, ciphertext []byte, err error) {
+	nonce, err = s.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nonce, s.aead.Seal(dst, nonce, plaintext, data), nil
+}
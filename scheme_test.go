@@ -0,0 +1,158 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestNonceScheme(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewScheme(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	seen := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		nonce, ciphertext, err := s.SealScheme(nil, plaintext, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen[string(nonce)] {
+			t.Fatalf("Nonce %x was reused", nonce)
+		}
+
+		seen[string(nonce)] = true
+
+		actual, err := c.Open(nil, nonce, ciphertext, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+		}
+	}
+}
+
+func TestNonceSchemeOverflow(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewScheme(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.rand = bytes.NewReader([]byte{1, 2, 3, 4})
+	s.prefix = [schemePrefixLen]byte{}
+	s.counter = math.MaxUint64 - 1
+
+	nonce, err := s.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0, 0, 0, 0, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if !bytes.Equal(expected, nonce) {
+		t.Errorf("Expected nonce %x, was %x", expected, nonce)
+	}
+
+	if nonce, err = s.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []byte{1, 2, 3, 4, 0, 0, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(expected, nonce) {
+		t.Errorf("Expected nonce %x, was %x", expected, nonce)
+	}
+
+	if _, err = s.Next(); err != nil {
+		t.Fatal(err)
+	}
+
+	s.counter = math.MaxUint64
+
+	if _, err = s.Next(); err == nil {
+		t.Error("Expected prefix refresh to fail with exhausted reader")
+	}
+}
+
+func TestNonceSchemeInvalidNonce(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewScheme(c); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestSequenced(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewSequenced(c, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		nonce, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []byte{1, 2, 3, 4, byte(i), 0, 0, 0, 0, 0, 0, 0}; !bytes.Equal(expected, nonce) {
+			t.Errorf("Expected nonce %x, was %x", expected, nonce)
+		}
+	}
+
+	s.counter = math.MaxUint64
+
+	if _, err = s.Next(); err != ErrNoncesExhausted {
+		t.Errorf("Expected nonces exhausted error but was %v", err)
+	}
+
+	for _, prefix := range [][]byte{nil, {1, 2, 3}, {1, 2, 3, 4, 5}} {
+		if _, err = NewSequenced(c, prefix); err != ErrInvalidPrefix {
+			t.Errorf("Expected invalid prefix error for %x but was %v", prefix, err)
+		}
+	}
+
+	d, err := NewDraft(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewSequenced(d, []byte{1, 2, 3, 4}); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
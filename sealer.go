@@ -0,0 +1,88 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// ErrNoncesExhausted is returned when a sequential nonce counter would wrap.
+// SealerState.Seal, which has no error result, panics with it instead.
+var ErrNoncesExhausted = errors.New("nonces exhausted")
+
+// SealerState is a stateful RFC7539 sealer for hot paths. It reuses all of
+// its scratch space between calls and derives each nonce from an internal
+// counter: four zero bytes followed by the 64-bit, little-endian counter,
+// starting from zero.
+//
+// As the counter always starts from zero, a key may only ever be used by a
+// single SealerState, and by nothing else that seals. A second SealerState
+// with the same key, including one created after a restart, repeats every
+// nonce and so loses all confidentiality and authenticity.
+//
+// A SealerState is not safe for concurrent use.
+type SealerState struct {
+	k chacha20Key
+
+	counter uint64
+	nonce   [RFCNonceSize]byte
+
+	pk [64]byte
+	m  bytes.Buffer
+}
+
+// NewSealerState creates a new SealerState using the given key. The key must
+// be exactly 256 bits long and must never be passed to NewSealerState again.
+func NewSealerState(key []byte) (*SealerState, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	s := new(SealerState)
+	copy(s.k.key[:], key)
+	return s, nil
+}
+
+// Counter returns the counter value that the next call to Seal will use.
+func (s *SealerState) Counter() uint64 {
+	return s.counter
+}
+
+// Seal encrypts and authenticates plaintext with the next nonce, appending
+// the result to dst. It panics with ErrNoncesExhausted if the counter would
+// wrap.
+func (s *SealerState) Seal(dst, plaintext, data []byte) []byte {
+	if s.counter == math.MaxUint64 {
+		panic(ErrNoncesExhausted)
+	}
+
+	if err := s.k.checkSeal(s.nonce[:], len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	binary.LittleEndian.PutUint64(s.nonce[4:], s.counter)
+	s.counter++
+
+	c := s.k.stream(s.nonce[:])
+
+	s.pk = [64]byte{}
+	c.XORKeyStream(s.pk[:], s.pk[:])
+
+	ret, out := sliceForAppend(dst, len(plaintext)+poly1305.TagSize)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	c.XORKeyStream(out, plaintext)
+
+	s.k.authBuffer(&s.m, s.pk[:32], out[len(plaintext):], out[:len(plaintext)], data)
+	wipe(s.pk[:])
+	return ret
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestSealerState(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	s, err := NewSealerState(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	for i := uint64(0); i < 10; i++ {
+		if s.Counter() != i {
+			t.Fatalf("Expected counter of %d but was %d", i, s.Counter())
+		}
+
+		nonce := make([]byte, c.NonceSize())
+		binary.LittleEndian.PutUint64(nonce[4:], i)
+
+		expected := c.Seal(nil, nonce, plaintext, data)
+		actual := s.Seal(nil, plaintext, data)
+
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal: expected %x, was %x", expected, actual)
+		}
+
+		if s.pk != [64]byte{} {
+			t.Errorf("Expected Poly1305 key to be wiped but was %x", s.pk)
+		}
+	}
+}
+
+func TestSealerStateExhausted(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	s, err := NewSealerState(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.counter = math.MaxUint64
+
+	defer func() {
+		if r := recover(); r != ErrNoncesExhausted {
+			t.Errorf("Expected nonces exhausted panic but was %v", r)
+		}
+	}()
+
+	s.Seal(nil, nil, nil)
+}
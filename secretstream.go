@@ -0,0 +1,252 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+const (
+	// SecretStreamHeaderSize is the size of the header returned by
+	// InitPush.
+	SecretStreamHeaderSize = XNonceSize
+
+	// SecretStreamOverhead is the difference between the length of a
+	// message and of its output from Push.
+	SecretStreamOverhead = 1 + poly1305.TagSize
+)
+
+// The tags that may be attached to a message with Push. Their meaning is
+// defined by the application, other than SecretStreamTagRekey, which also
+// causes the stream to be rekeyed after the message.
+const (
+	// SecretStreamTagMessage is the tag of an ordinary message.
+	SecretStreamTagMessage byte = 0x00
+
+	// SecretStreamTagPush marks the end of a set of messages, but not of
+	// the stream.
+	SecretStreamTagPush byte = 0x01
+
+	// SecretStreamTagRekey rekeys the stream after the message.
+	SecretStreamTagRekey byte = 0x02
+
+	// SecretStreamTagFinal marks the end of the stream. It implies
+	// SecretStreamTagRekey.
+	SecretStreamTagFinal = SecretStreamTagPush | SecretStreamTagRekey
+)
+
+const (
+	secretStreamCounterSize = 4
+	secretStreamINonceSize  = 8
+)
+
+// SecretStream encrypts or decrypts a sequence of messages, compatibly with
+// libsodium's crypto_secretstream_xchacha20poly1305. Messages can't be
+// reordered, dropped or duplicated without detection, and each carries a tag
+// that, with SecretStreamTagFinal, allows truncation of the stream to be
+// detected.
+//
+// A SecretStream returned by InitPush may only be used with Push, and one
+// returned by InitPull may only be used with Pull. A SecretStream is not safe
+// for concurrent use.
+type SecretStream struct {
+	k     [KeySize]byte
+	nonce [RFCNonceSize]byte // counter || inonce
+}
+
+// InitPush returns a SecretStream for encrypting messages with key, and a
+// random header which must be sent to the recipient before the first message.
+// The key must be exactly 256 bits long.
+func InitPush(key []byte) (s *SecretStream, header []byte, err error) {
+	if len(key) != KeySize {
+		return nil, nil, ErrInvalidKey
+	}
+
+	header = make([]byte, SecretStreamHeaderSize)
+	if _, err := io.ReadFull(rand.Reader, header); err != nil {
+		return nil, nil, err
+	}
+
+	s, err = InitPull(key, header)
+	return s, header, err
+}
+
+// InitPull returns a SecretStream for decrypting messages with key, using the
+// header produced by InitPush. ErrInvalidKey or ErrInvalidNonce is returned if
+// key or header is the wrong size.
+func InitPull(key, header []byte) (*SecretStream, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+
+	if len(header) != SecretStreamHeaderSize {
+		return nil, ErrInvalidNonce
+	}
+
+	k, err := HChaCha20(key, header[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(SecretStream)
+	copy(s.k[:], k)
+	wipe(k)
+
+	copy(s.nonce[secretStreamCounterSize:], header[16:])
+	s.resetCounter()
+	return s, nil
+}
+
+func (s *SecretStream) resetCounter() {
+	binary.LittleEndian.PutUint32(s.nonce[:secretStreamCounterSize], 1)
+}
+
+func (s *SecretStream) stream() cipher.Stream {
+	c, err := newChaCha20(s.k[:], s.nonce[:])
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	return c
+}
+
+// Rekey replaces the key and nonce of the stream with ones derived from their
+// current values. It must be called at the same point in the stream by both
+// the sender and the recipient. It is done automatically after a message
+// tagged with SecretStreamTagRekey.
+func (s *SecretStream) Rekey() {
+	var buf [KeySize + secretStreamINonceSize]byte
+	copy(buf[:], s.k[:])
+	copy(buf[KeySize:], s.nonce[secretStreamCounterSize:])
+
+	s.stream().XORKeyStream(buf[:], buf[:])
+
+	copy(s.k[:], buf[:])
+	copy(s.nonce[secretStreamCounterSize:], buf[KeySize:])
+	wipe(buf[:])
+
+	s.resetCounter()
+}
+
+// begin returns the cipher and MAC for the next message, with the MAC
+// positioned after the padded additional data and the cipher at the start of
+// the counter-1 block.
+func (s *SecretStream) begin(ad []byte) (cipher.Stream, *poly1305.MAC) {
+	c := s.stream()
+
+	var block [64]byte
+	c.XORKeyStream(block[:], block[:])
+
+	var pk [32]byte
+	copy(pk[:], block[:32])
+	wipe(block[:])
+
+	m := poly1305.New(&pk)
+	wipe(pk[:])
+
+	var zero [poly1305PadLen]byte
+	m.Write(ad)
+	m.Write(zero[:Pad16Len(len(ad))])
+	return c, m
+}
+
+// finishSecretStreamMAC pads the ciphertext, authenticates the lengths and
+// returns the tag. The 64-byte tag block is included in the ciphertext length.
+//
+// libsodium computes the ciphertext padding as (0x10 - 64 + ctLen) & 0xf,
+// which is ctLen%16 zero bytes rather than the number needed to reach a
+// multiple of 16. That is mirrored here for compatibility.
+func finishSecretStreamMAC(m *poly1305.MAC, adLen, ctLen int) [poly1305.TagSize]byte {
+	var zero [poly1305PadLen]byte
+	m.Write(zero[:ctLen%poly1305PadLen])
+
+	var lens [16]byte
+	binary.LittleEndian.PutUint64(lens[:8], uint64(adLen))
+	binary.LittleEndian.PutUint64(lens[8:], uint64(64+ctLen))
+	m.Write(lens[:])
+
+	var mac [poly1305.TagSize]byte
+	m.Sum(mac[:0])
+	return mac
+}
+
+// advance updates the nonce after a message, rekeying if required.
+func (s *SecretStream) advance(mac *[poly1305.TagSize]byte, tag byte) {
+	for i := 0; i < secretStreamINonceSize; i++ {
+		s.nonce[secretStreamCounterSize+i] ^= mac[i]
+	}
+
+	counter := binary.LittleEndian.Uint32(s.nonce[:secretStreamCounterSize]) + 1
+	binary.LittleEndian.PutUint32(s.nonce[:secretStreamCounterSize], counter)
+
+	if tag&SecretStreamTagRekey != 0 || counter == 0 {
+		s.Rekey()
+	}
+}
+
+// Push encrypts and authenticates plaintext and tag, along with the
+// additional data ad, and returns the result, which is SecretStreamOverhead
+// bytes longer than plaintext.
+func (s *SecretStream) Push(plaintext, ad []byte, tag byte) []byte {
+	c, m := s.begin(ad)
+
+	var block [64]byte
+	block[0] = tag
+	c.XORKeyStream(block[:], block[:])
+	m.Write(block[:])
+
+	out := make([]byte, SecretStreamOverhead+len(plaintext))
+	out[0] = block[0]
+
+	ciphertext := out[1 : 1+len(plaintext)]
+	c.XORKeyStream(ciphertext, plaintext)
+	m.Write(ciphertext)
+
+	mac := finishSecretStreamMAC(m, len(ad), len(ciphertext))
+	copy(out[1+len(plaintext):], mac[:])
+
+	s.advance(&mac, tag)
+	return out
+}
+
+// Pull authenticates and decrypts ciphertext, produced by Push, along with
+// the additional data ad, and returns the plaintext and tag. ErrAuthFailed is
+// returned if the message is not authentic or is out of order, and
+// ErrCiphertextTooShort if it is too short to have been produced by Push. The
+// stream is not advanced if an error is returned.
+func (s *SecretStream) Pull(ciphertext, ad []byte) (plaintext []byte, tag byte, err error) {
+	if len(ciphertext) < SecretStreamOverhead {
+		return nil, 0, ErrCiphertextTooShort
+	}
+
+	c, m := s.begin(ad)
+
+	var block [64]byte
+	block[0] = ciphertext[0]
+	c.XORKeyStream(block[:], block[:])
+	tag = block[0]
+	block[0] = ciphertext[0]
+	m.Write(block[:])
+
+	body := ciphertext[1 : len(ciphertext)-poly1305.TagSize]
+	m.Write(body)
+
+	mac := finishSecretStreamMAC(m, len(ad), len(body))
+	if subtle.ConstantTimeCompare(mac[:], ciphertext[len(ciphertext)-poly1305.TagSize:]) != 1 {
+		return nil, 0, ErrAuthFailed
+	}
+
+	plaintext = make([]byte, len(body))
+	c.XORKeyStream(plaintext, body)
+
+	s.advance(&mac, tag)
+	return plaintext, tag, nil
+}
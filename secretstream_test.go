@@ -0,0 +1,184 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+type secretStreamMessage struct {
+	rekey      bool // call Rekey rather than Push or Pull
+	plaintext  []byte
+	ad         []byte
+	tag        byte
+	ciphertext []byte
+}
+
+// secretStreamVectors were produced by libsodium 1.0.18's
+// crypto_secretstream_xchacha20poly1305 with the given key and header.
+var secretStreamVectors = struct {
+	key, header []byte
+	messages    []secretStreamMessage
+}{
+	key:    mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+	header: mustHexDecode("aab0915b20d2f71b6e6d71bcf72bd78afc0a9cca7bbc715e"),
+	messages: []secretStreamMessage{
+		{
+			plaintext:  []byte("Ladies and Gentlemen"),
+			tag:        SecretStreamTagMessage,
+			ciphertext: mustHexDecode("54f1d105be4bb334a68beb4d7ccf06574bc8acb239a0c7e42f900a85819551369837ff9374"),
+		},
+		{
+			plaintext:  []byte("of the class of '99"),
+			ad:         []byte("ad"),
+			tag:        SecretStreamTagPush,
+			ciphertext: mustHexDecode("0f309f81e793d35636e8a93daefcef360c6ff9768039e7e67eb228f0a3776b986d13ae12"),
+		},
+		{
+			plaintext:  []byte{},
+			tag:        SecretStreamTagMessage,
+			ciphertext: mustHexDecode("1015927a6aa638bd6b28c86a69b02a7b9f"),
+		},
+		{
+			plaintext: bytes.Repeat([]byte("If I could offer you only one tip for the future, sunscreen would be it."), 2),
+			ad:        []byte("more ad"),
+			tag:       SecretStreamTagRekey,
+			ciphertext: mustHexDecode("2f27571838f7bb9dce50d5e3bcc769234437e563aaa34901eab646843188148e" +
+				"51ef3defba2b79abc7f06b8df774f2a0cb356b71ce9354fd99ab1bac482858dc" +
+				"b9f4c5ce4dc38de96274a7f11a5dcbd4b78f951ef23c91ddbf896059652c63e0" +
+				"c9dfa49ddbfea54622fcaafa14ba4761d01c435b8d5a23032873375e1657426a" +
+				"c9f492034e9eb9e31a409409d56cb2974679e3c7ffbdae300f850945ba833eda26"),
+		},
+		{
+			rekey: true,
+		},
+		{
+			plaintext:  []byte("after rekey"),
+			tag:        SecretStreamTagMessage,
+			ciphertext: mustHexDecode("6a2b84d54d60b7b12e38d76ff05f5714cfa6f3f9a7f771458c2aa80d"),
+		},
+		{
+			plaintext:  []byte("the end"),
+			ad:         []byte("fin"),
+			tag:        SecretStreamTagFinal,
+			ciphertext: mustHexDecode("53c4cf2135243e4905954b3a3f411c0da4b7250dd03c4a3a"),
+		},
+	},
+}
+
+func TestSecretStreamPull(t *testing.T) {
+	s, err := InitPull(secretStreamVectors.key, secretStreamVectors.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, msg := range secretStreamVectors.messages {
+		if msg.rekey {
+			s.Rekey()
+			continue
+		}
+
+		plaintext, tag, err := s.Pull(msg.ciphertext, msg.ad)
+		if err != nil {
+			t.Fatalf("Message %d: %v", i, err)
+		}
+
+		if !bytes.Equal(msg.plaintext, plaintext) {
+			t.Errorf("Bad pull of message %d: expected %x, was %x", i, msg.plaintext, plaintext)
+		}
+
+		if tag != msg.tag {
+			t.Errorf("Bad pull of message %d: expected tag %#x, was %#x", i, msg.tag, tag)
+		}
+	}
+}
+
+func TestSecretStreamPush(t *testing.T) {
+	// InitPush chooses a random header, so initialise the stream as the
+	// vectors' sender did.
+	s, err := InitPull(secretStreamVectors.key, secretStreamVectors.header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, msg := range secretStreamVectors.messages {
+		if msg.rekey {
+			s.Rekey()
+			continue
+		}
+
+		if actual := s.Push(msg.plaintext, msg.ad, msg.tag); !bytes.Equal(msg.ciphertext, actual) {
+			t.Errorf("Bad push of message %d: expected %x, was %x", i, msg.ciphertext, actual)
+		}
+	}
+}
+
+func TestSecretStreamRoundtrip(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	push, header, err := InitPush(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(header) != SecretStreamHeaderSize {
+		t.Fatalf("Expected header of %d bytes but was %d", SecretStreamHeaderSize, len(header))
+	}
+
+	pull, err := InitPull(key, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := push.Push([]byte("first"), nil, SecretStreamTagMessage)
+	second := push.Push([]byte("second"), nil, SecretStreamTagFinal)
+
+	if len(first) != len("first")+SecretStreamOverhead {
+		t.Errorf("Expected %d bytes but was %d", len("first")+SecretStreamOverhead, len(first))
+	}
+
+	// Out of order messages fail and don't advance the stream.
+	if _, _, err := pull.Pull(second, nil); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	tampered := append([]byte(nil), first...)
+	tampered[0] ^= 1
+
+	if _, _, err := pull.Pull(tampered, nil); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+
+	if _, _, err := pull.Pull(first[:SecretStreamOverhead-1], nil); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+
+	for _, msg := range []struct {
+		ciphertext []byte
+		plaintext  string
+		tag        byte
+	}{
+		{first, "first", SecretStreamTagMessage},
+		{second, "second", SecretStreamTagFinal},
+	} {
+		plaintext, tag, err := pull.Pull(msg.ciphertext, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(plaintext) != msg.plaintext || tag != msg.tag {
+			t.Errorf("Bad pull: expected %q with tag %#x, was %q with tag %#x", msg.plaintext, msg.tag, plaintext, tag)
+		}
+	}
+
+	if _, err := InitPull(key, header[:23]); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+
+	if _, _, err := InitPush(key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"errors"
+)
+
+// ErrUnknownAlgorithm is returned by OpenSelfDescribing when the header
+// identifies an unknown construction.
+var ErrUnknownAlgorithm = errors.New("unknown algorithm")
+
+// Algorithm identifiers stored in the high nibble of a self-describing
+// header.
+const (
+	algRFC   byte = 0x1
+	algDraft byte = 0x2
+)
+
+// SelfDescribingAEAD is implemented by the AEADs returned from NewRFC and
+// NewDraft. It produces ciphertexts that identify the construction used to
+// seal them, so that they can be opened with OpenSelfDescribing given only the
+// key.
+//
+// The output is a one byte header, the nonce and then the ciphertext and tag.
+// The high nibble of the header identifies the construction and the low
+// nibble holds the tag size minus one. The header is prepended to the
+// additional data, so it is authenticated.
+type SelfDescribingAEAD interface {
+	cipher.AEAD
+
+	// SealSelfDescribing behaves like Seal but prepends the header and the
+	// nonce to the ciphertext.
+	SealSelfDescribing(dst, nonce, plaintext, data []byte) []byte
+}
+
+func (k *chacha20Key) header() byte {
+	alg := algRFC
+	if k.draft {
+		alg = algDraft
+	}
+
+	return alg<<4 | byte(k.Overhead()-1)
+}
+
+func (k *chacha20Key) SealSelfDescribing(dst, nonce, plaintext, data []byte) []byte {
+	if len(nonce) != k.NonceSize() {
+		panic(ErrInvalidNonce)
+	}
+
+	h := k.header()
+
+	ret, out := sliceForAppend(dst, 1+len(nonce))
+	out[0] = h
+	copy(out[1:], nonce)
+
+	return k.Seal(ret, nonce, plaintext, selfDescribingData(h, data))
+}
+
+// OpenSelfDescribing opens a ciphertext produced by SealSelfDescribing,
+// selecting the construction from its header.
+func OpenSelfDescribing(key, ciphertext, data []byte) ([]byte, error) {
+	if len(ciphertext) < 1 {
+		return nil, ErrCiphertextTooShort
+	}
+
+	h := ciphertext[0]
+
+	var (
+		c   cipher.AEAD
+		err error
+	)
+	switch h >> 4 {
+	case algRFC:
+		c, err = NewRFC(key)
+	case algDraft:
+		c, err = NewDraft(key)
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if k := c.(*chacha20Key); k.header() != h {
+		return nil, ErrUnknownAlgorithm
+	}
+
+	if len(ciphertext) < 1+c.NonceSize() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce := ciphertext[1 : 1+c.NonceSize()]
+	return c.Open(nil, nonce, ciphertext[1+c.NonceSize():], selfDescribingData(h, data))
+}
+
+func selfDescribingData(h byte, data []byte) []byte {
+	ad := make([]byte, 1+len(data))
+	ad[0] = h
+	copy(ad[1:], data)
+	return ad
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testSelfDescribing(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), header byte) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+
+	ciphertext := c.(SelfDescribingAEAD).SealSelfDescribing(nil, nonce, plaintext, data)
+
+	if ciphertext[0] != header {
+		t.Errorf("Expected header of %#02x but was %#02x", header, ciphertext[0])
+	}
+
+	actual, err := OpenSelfDescribing(key, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", plaintext, actual)
+	}
+
+	// The header is authenticated, so switching construction or tag size
+	// must fail.
+	for _, h := range []byte{algRFC<<4 | 0xf, algDraft<<4 | 0xf} {
+		if h == header {
+			continue
+		}
+
+		tampered := append([]byte{h}, ciphertext[1:]...)
+		if _, err = OpenSelfDescribing(key, tampered, data); err == nil {
+			t.Errorf("Expected header %#02x to fail", h)
+		}
+	}
+
+	tampered := append([]byte{header ^ 0x01}, ciphertext[1:]...)
+	if _, err = OpenSelfDescribing(key, tampered, data); err != ErrUnknownAlgorithm {
+		t.Errorf("Expected unknown algorithm error but was %v", err)
+	}
+
+	if _, err = OpenSelfDescribing(key, ciphertext[:c.NonceSize()], data); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+}
+
+func TestRFCSelfDescribing(t *testing.T) {
+	testSelfDescribing(t, NewRFC, 0x1f)
+}
+
+func TestDraftSelfDescribing(t *testing.T) {
+	testSelfDescribing(t, NewDraft, 0x2f)
+}
+
+func TestSelfDescribingUnknownAlgorithm(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	if _, err := OpenSelfDescribing(key, []byte{0xff}, nil); err != ErrUnknownAlgorithm {
+		t.Errorf("Expected unknown algorithm error but was %v", err)
+	}
+}
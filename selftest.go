@@ -0,0 +1,92 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"errors"
+)
+
+// ErrSelfTestFailed is returned by SelfTest when a known answer test fails.
+var ErrSelfTestFailed = errors.New("self test failed")
+
+type selfTestVector struct {
+	new func(key []byte) (cipher.AEAD, error)
+
+	key, plaintext, nonce, data, ciphertext string
+}
+
+var selfTestVectors = []selfTestVector{
+	// https://tools.ietf.org/html/rfc7539#section-2.8.2
+	{
+		NewRFC,
+		"808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f",
+		"4c616469657320616e642047656e746c656d656e206f662074686520636c6173" +
+			"73206f66202739393a204966204920636f756c64206f6666657220796f75206f" +
+			"6e6c79206f6e652074697020666f7220746865206675747572652c2073756e73" +
+			"637265656e20776f756c642062652069742e",
+		"070000004041424344454647",
+		"50515253c0c1c2c3c4c5c6c7",
+		"d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6" +
+			"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36" +
+			"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc" +
+			"3ff4def08e4b7a9de576d26586cec64b6116" +
+			"1ae10b594f09e26a7e902ecbd0600691",
+	},
+	// http://tools.ietf.org/html/draft-agl-tls-chacha20poly1305-02#section-7
+	{
+		NewDraft,
+		"4290bcb154173531f314af57f3be3b5006da371ece272afa1b5dbdd1100a1007",
+		"86d09974840bded2a5ca",
+		"cd7cf67be39c794a",
+		"87e229d4500845a079c0",
+		"e3e446f7ede9a19b62a4677dabf4e3d24b876bb284753896e1d6",
+	},
+}
+
+// SelfTest runs known answer tests for both constructions through Seal and
+// Open. It returns ErrSelfTestFailed if any output doesn't match, which would
+// indicate a broken ChaCha20 or Poly1305 implementation on this platform.
+func SelfTest() error {
+	for _, v := range selfTestVectors {
+		c, err := v.new(mustDecodeHex(v.key))
+		if err != nil {
+			return err
+		}
+
+		plaintext := mustDecodeHex(v.plaintext)
+		nonce := mustDecodeHex(v.nonce)
+		data := mustDecodeHex(v.data)
+		ciphertext := mustDecodeHex(v.ciphertext)
+
+		if !bytes.Equal(c.Seal(nil, nonce, plaintext, data), ciphertext) {
+			return ErrSelfTestFailed
+		}
+
+		out, err := c.Open(nil, nonce, ciphertext, data)
+		if err != nil || !bytes.Equal(out, plaintext) {
+			return ErrSelfTestFailed
+		}
+
+		ciphertext[0] ^= 1
+
+		if _, err = c.Open(nil, nonce, ciphertext, data); err != ErrAuthFailed {
+			return ErrSelfTestFailed
+		}
+	}
+
+	return nil
+}
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
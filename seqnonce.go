@@ -0,0 +1,39 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrSeqTooLarge is returned by NonceForSeq when the sequence number doesn't
+// fit in a nonce of the requested size.
+var ErrSeqTooLarge = errors.New("sequence number too large for nonce size")
+
+// NonceForSeq returns a nonce of size bytes encoding seq as a little-endian
+// integer, zero-padded to size. Peers that agree on the sequence number of a
+// message therefore derive the same nonce independently. Each sequence number
+// must only be used once for a given key.
+//
+// ErrInvalidNonce is returned if size isn't positive. The nonce sizes used in
+// this package are all large enough to hold any sequence number, but
+// ErrSeqTooLarge is returned if a smaller size can't hold seq.
+func NonceForSeq(seq uint64, size int) ([]byte, error) {
+	if size <= 0 {
+		return nil, ErrInvalidNonce
+	}
+
+	if size < 8 && seq>>(8*uint(size)) != 0 {
+		return nil, ErrSeqTooLarge
+	}
+
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], seq)
+
+	nonce := make([]byte, size)
+	copy(nonce, b[:])
+	return nonce, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestNonceForSeq(t *testing.T) {
+	for _, size := range []int{DraftNonceSize, RFCNonceSize, XNonceSize} {
+		for _, seq := range []uint64{0, 1, 0x0102030405060708, math.MaxUint64} {
+			nonce, err := NonceForSeq(seq, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := make([]byte, size)
+			for i := 0; i < 8; i++ {
+				expected[i] = byte(seq >> (8 * uint(i)))
+			}
+
+			if !bytes.Equal(expected, nonce) {
+				t.Errorf("Bad nonce for %d in %d bytes: expected %x, was %x", seq, size, expected, nonce)
+			}
+		}
+	}
+
+	nonce, err := NonceForSeq(0x01020304, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []byte{0x04, 0x03, 0x02, 0x01}; !bytes.Equal(expected, nonce) {
+		t.Errorf("Bad nonce: expected %x, was %x", expected, nonce)
+	}
+}
+
+func TestNonceForSeqOverflow(t *testing.T) {
+	for _, test := range []struct {
+		seq  uint64
+		size int
+	}{
+		{1 << 32, 4},
+		{256, 1},
+		{math.MaxUint64, 7},
+	} {
+		if _, err := NonceForSeq(test.seq, test.size); err != ErrSeqTooLarge {
+			t.Errorf("Expected sequence number too large error for %d in %d bytes but was %v", test.seq, test.size, err)
+		}
+	}
+
+	for _, size := range []int{0, -1} {
+		if _, err := NonceForSeq(0, size); err != ErrInvalidNonce {
+			t.Errorf("Expected invalid nonce error for size %d but was %v", size, err)
+		}
+	}
+}
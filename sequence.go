@@ -0,0 +1,66 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "sync"
+
+// NonceSequence generates sequential nonces, starting from zero. Each nonce
+// is a little-endian counter spanning the whole nonce, so no two nonces from
+// a NonceSequence are ever equal. Once every nonce has been returned, Next
+// returns ErrNoncesExhausted rather than wrapping.
+//
+// A NonceSequence is safe for concurrent use.
+type NonceSequence struct {
+	mu        sync.Mutex
+	counter   [XNonceSize]byte
+	size      int
+	exhausted bool
+}
+
+// NewDraftNonceSequence returns a NonceSequence of 8-byte nonces for use with
+// NewDraft.
+func NewDraftNonceSequence() *NonceSequence {
+	return &NonceSequence{size: DraftNonceSize}
+}
+
+// NewRFCNonceSequence returns a NonceSequence of 12-byte nonces for use with
+// NewRFC.
+func NewRFCNonceSequence() *NonceSequence {
+	return &NonceSequence{size: RFCNonceSize}
+}
+
+// NewXNonceSequence returns a NonceSequence of 24-byte nonces for use with
+// NewX.
+func NewXNonceSequence() *NonceSequence {
+	return &NonceSequence{size: XNonceSize}
+}
+
+// NonceSize returns the size of the nonces returned by Next.
+func (s *NonceSequence) NonceSize() int {
+	return s.size
+}
+
+// Next returns the next nonce in the sequence.
+func (s *NonceSequence) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exhausted {
+		return nil, ErrNoncesExhausted
+	}
+
+	nonce := make([]byte, s.size)
+	copy(nonce, s.counter[:s.size])
+
+	s.exhausted = true
+	for i := range s.counter[:s.size] {
+		if s.counter[i]++; s.counter[i] != 0 {
+			s.exhausted = false
+			break
+		}
+	}
+
+	return nonce, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testNonceSequence(t *testing.T, newSequence func() *NonceSequence, size int) {
+	s := newSequence()
+
+	if s.NonceSize() != size {
+		t.Errorf("Expected nonce size of %d but was %d", size, s.NonceSize())
+	}
+
+	seen := make(map[string]bool)
+
+	for i := 0; i < 1000; i++ {
+		nonce, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(nonce) != size {
+			t.Fatalf("Expected %d byte nonce but was %d bytes", size, len(nonce))
+		}
+
+		if seen[string(nonce)] {
+			t.Fatalf("Nonce %x was repeated", nonce)
+		}
+
+		seen[string(nonce)] = true
+	}
+
+	expected := make([]byte, size)
+	expected[0], expected[1] = 0xe8, 0x03 // 1000
+
+	if nonce, _ := s.Next(); !bytes.Equal(expected, nonce) {
+		t.Errorf("Bad nonce: expected %x, was %x", expected, nonce)
+	}
+
+	s = newSequence()
+	for i := range s.counter[:size] {
+		s.counter[i] = 0xff
+	}
+	s.counter[0] = 0xfe
+
+	for _, expected := range []byte{0xfe, 0xff} {
+		nonce, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if nonce[0] != expected || !bytes.Equal(nonce[1:], bytes.Repeat([]byte{0xff}, size-1)) {
+			t.Errorf("Bad nonce: expected %02x%x, was %x", expected, bytes.Repeat([]byte{0xff}, size-1), nonce)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if nonce, err := s.Next(); err != ErrNoncesExhausted || nonce != nil {
+			t.Errorf("Expected nonces exhausted error but was %v (%x)", err, nonce)
+		}
+	}
+}
+
+func TestDraftNonceSequence(t *testing.T) {
+	testNonceSequence(t, NewDraftNonceSequence, DraftNonceSize)
+}
+
+func TestRFCNonceSequence(t *testing.T) {
+	testNonceSequence(t, NewRFCNonceSequence, RFCNonceSize)
+}
+
+func TestXNonceSequence(t *testing.T) {
+	testNonceSequence(t, NewXNonceSequence, XNonceSize)
+}
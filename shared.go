@@ -0,0 +1,80 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"golang.org/x/crypto/poly1305"
+)
+
+// SharedAADSealer seals many messages under one key with the same additional
+// data. Nonces are generated by a NonceScheme and the encoding of the
+// additional data for Poly1305, including its padding and length, is
+// computed once and reused for every message.
+//
+// A SharedAADSealer is safe for concurrent use.
+type SharedAADSealer struct {
+	k *chacha20Key
+
+	nonces *NonceScheme
+
+	dataLen uint64
+	macData []byte // the encoded additional data
+}
+
+// NewSharedAADSealer creates a new RFC7539 SharedAADSealer using the given key
+// and additional data. The key must be exactly 256 bits long.
+func NewSharedAADSealer(key, data []byte) (*SharedAADSealer, error) {
+	c, err := NewRFC(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonces, err := NewScheme(c)
+	if err != nil {
+		return nil, err
+	}
+
+	k := c.(*chacha20Key)
+
+	macData := make([]byte, roundUp(uint64(len(data)), poly1305PadLen))
+	copy(macData, data)
+
+	return &SharedAADSealer{
+		k:      k,
+		nonces: nonces,
+
+		dataLen: uint64(len(data)),
+		macData: macData,
+	}, nil
+}
+
+// Seal encrypts and authenticates plaintext with a fresh nonce and the shared
+// additional data. It returns the nonce, which must be conveyed to the
+// recipient, and the ciphertext.
+func (s *SharedAADSealer) Seal(plaintext []byte) (nonce, ciphertext []byte) {
+	nonce, err := s.nonces.Next()
+	if err != nil {
+		panic(err)
+	}
+
+	if err := s.k.checkSeal(nonce, len(plaintext), s.dataLen); err != nil {
+		panic(err)
+	}
+
+	c := s.k.stream(nonce)
+
+	var pk [64]byte
+	c.XORKeyStream(pk[:], pk[:])
+
+	ciphertext = make([]byte, len(plaintext)+poly1305.TagSize)
+	c.XORKeyStream(ciphertext, plaintext)
+
+	mac := s.k.newMACWriter(pk[:32])
+	mac.writeEncodedData(s.macData, s.dataLen)
+	mac.Write(ciphertext[:len(plaintext)])
+	mac.sum(ciphertext[len(plaintext):])
+
+	return nonce, ciphertext
+}
@@ -0,0 +1,43 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSharedAADSealer(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, l := range []int{0, 1, 15, 16, 17, 1024} {
+		t.Run(fmt.Sprint(l), func(t *testing.T) {
+			data := bytes.Repeat([]byte{0x42}, l)
+
+			s, err := NewSharedAADSealer(key, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, plaintext := range [][]byte{nil, []byte("yay for me"), bytes.Repeat([]byte{1}, 100)} {
+				nonce, ciphertext := s.Seal(plaintext)
+
+				if expected := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, ciphertext) {
+					t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+				}
+			}
+		})
+	}
+
+	if _, err = NewSharedAADSealer(key[:31], nil); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"testing"
 
 	codahale "github.com/codahale/chacha20poly1305"
+	"github.com/tmthrgd/chacha20"
 	xcrypto "golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/poly1305"
 )
 
 type size struct {
@@ -32,6 +34,7 @@ func benchmarkAEAD(b *testing.B, c cipher.AEAD, l int) {
 	output := make([]byte, 0, l+c.Overhead())
 	nonce := make([]byte, c.NonceSize())
 
+	b.ReportAllocs()
 	b.SetBytes(int64(l))
 	b.ResetTimer()
 
@@ -40,6 +43,18 @@ func benchmarkAEAD(b *testing.B, c cipher.AEAD, l int) {
 	}
 }
 
+func benchmarkAEADNilDst(b *testing.B, c cipher.AEAD, l int) {
+	input := make([]byte, l)
+	nonce := make([]byte, c.NonceSize())
+
+	b.SetBytes(int64(l))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		c.Seal(nil, nonce, input, nil)
+	}
+}
+
 func BenchmarkDraftChaCha20Poly1305Codahale(b *testing.B) {
 	for _, size := range sizes {
 		b.Run(size.name, func(b *testing.B) {
@@ -62,6 +77,21 @@ func BenchmarkRFCChaCha20Poly1305(b *testing.B) {
 	}
 }
 
+func BenchmarkRFCChaCha20Poly1305Dst(b *testing.B) {
+	key := make([]byte, KeySize)
+	c, _ := NewRFC(key)
+
+	for _, size := range sizes {
+		b.Run("Presized/"+size.name, func(b *testing.B) {
+			benchmarkAEAD(b, c, size.l)
+		})
+
+		b.Run("Nil/"+size.name, func(b *testing.B) {
+			benchmarkAEADNilDst(b, c, size.l)
+		})
+	}
+}
+
 func BenchmarkDraftChaCha20Poly1305(b *testing.B) {
 	for _, size := range sizes {
 		b.Run(size.name, func(b *testing.B) {
@@ -95,3 +125,145 @@ func BenchmarkAESGCM(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkKeystreamOnly(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			key := make([]byte, KeySize)
+			nonce := make([]byte, chacha20.RFCNonceSize)
+
+			input := make([]byte, size.l)
+			output := make([]byte, size.l)
+
+			b.SetBytes(int64(size.l))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				c, _ := chacha20.New(key, nonce)
+
+				var pk [64]byte
+				c.XORKeyStream(pk[:], pk[:])
+
+				c.XORKeyStream(output, input)
+			}
+		})
+	}
+}
+
+func BenchmarkMACOnly(b *testing.B) {
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			k := new(chacha20Key)
+
+			var pk [32]byte
+			var tag [poly1305.TagSize]byte
+			input := make([]byte, size.l)
+
+			b.SetBytes(int64(size.l))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				k.auth(pk[:], tag[:], input, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkSealerState(b *testing.B) {
+	const l = 128
+
+	key := make([]byte, KeySize)
+	s, _ := NewSealerState(key)
+
+	input := make([]byte, l)
+	output := make([]byte, 0, l+poly1305.TagSize)
+
+	b.SetBytes(l)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		s.Seal(output, input, nil)
+	}
+}
+
+func BenchmarkSharedAADSealer(b *testing.B) {
+	key := make([]byte, KeySize)
+	data := make([]byte, 1024)
+	input := make([]byte, 32)
+
+	b.Run("Seal", func(b *testing.B) {
+		c, _ := NewRFC(key)
+		nonces, _ := NewScheme(c)
+
+		b.SetBytes(int64(len(input)))
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			nonce, _ := nonces.Next()
+			c.Seal(nil, nonce, input, data)
+		}
+	})
+
+	b.Run("SharedAADSealer", func(b *testing.B) {
+		s, _ := NewSharedAADSealer(key, data)
+
+		b.SetBytes(int64(len(input)))
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			s.Seal(input)
+		}
+	})
+}
+
+func BenchmarkOneShotSeal(b *testing.B) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, chacha20.RFCNonceSize)
+	input := make([]byte, 1024)
+
+	for _, bench := range []struct {
+		name string
+		new  func(key []byte) (cipher.AEAD, error)
+	}{
+		{"Pool", NewRFC},
+		{"NoPool", NewRFCNoPool},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+
+			for i := 0; i < b.N; i++ {
+				c, _ := bench.new(key)
+				c.Seal(nil, nonce, input, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkKeyBlock(b *testing.B) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, chacha20.RFCNonceSize)
+	input := make([]byte, 1024)
+	output := make([]byte, 0, len(input)+poly1305.TagSize)
+
+	for _, bench := range []struct {
+		name string
+		new  func(key []byte) (cipher.AEAD, error)
+	}{
+		{"Pool", NewRFC},
+		{"NoPool", NewRFCNoPool},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			c, _ := bench.new(key)
+			ciphertext := c.Seal(nil, nonce, input, nil)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				c.Seal(output, nonce, input, nil)
+				c.Open(output[:0], nonce, ciphertext, nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+const (
+	// streamChunkSize is the maximum amount of plaintext sealed in each
+	// frame of an encrypted stream.
+	streamChunkSize = 64 * 1024
+
+	streamCounterSize = 4
+	streamHeaderSize  = 1 + 4
+
+	streamFrameMore  = 0x00
+	streamFrameFinal = 0x01
+)
+
+// errStreamClosed is returned when writing to a closed encrypting writer.
+var errStreamClosed = errors.New("chacha20poly1305: write to closed stream")
+
+// streamNonce returns the nonce for frame counter of a stream with the given
+// nonce prefix.
+func streamNonce(prefix []byte, counter uint64) []byte {
+	nonce := make([]byte, len(prefix)+streamCounterSize)
+	copy(nonce, prefix)
+	binary.LittleEndian.PutUint32(nonce[len(prefix):], uint32(counter))
+	return nonce
+}
+
+// streamData returns the additional data for a frame with the given flag.
+func streamData(flag byte, data []byte) []byte {
+	out := make([]byte, 1+len(data))
+	out[0] = flag
+	copy(out[1:], data)
+	return out
+}
+
+type encryptingWriter struct {
+	aead cipher.AEAD
+	w    io.Writer
+
+	nonce []byte
+	more  []byte // additional data for non-final frames
+	final []byte // additional data for the final frame
+
+	counter uint64
+	buf     []byte
+	out     []byte
+
+	err error
+}
+
+// NewEncryptingWriter returns an io.WriteCloser that encrypts everything
+// written to it with aead and writes it to w, buffering up to 64KiB of
+// plaintext at a time. nonce must be 4 bytes shorter than aead.NonceSize(),
+// otherwise ErrInvalidNonce is returned; it must never be reused with the same
+// key.
+//
+// Close must be called to write the last frame; it does not close w. A
+// stream may be at most 2^32 frames long, after which ErrNoncesExhausted is
+// returned.
+//
+// The stream is a sequence of frames, each of which is:
+//
+//	flag (1 byte) || length (4 bytes, little-endian) || sealed chunk
+//
+// where the sealed chunk is length bytes long, and is the output of Seal for
+// at most 64KiB of plaintext. The flag is 0x01 for the last frame of the
+// stream and 0x00 for every other frame. Every frame other than the last
+// holds exactly 64KiB of plaintext.
+//
+// The nonce for the i'th frame, counting from zero, is nonce followed by i as
+// a 4-byte, little-endian value. The additional data for each frame is the
+// flag followed by data. Binding the counter and flag means frames can't be
+// reordered, dropped or duplicated, and the stream can't be truncated at a
+// frame boundary, without detection.
+func NewEncryptingWriter(aead cipher.AEAD, w io.Writer, nonce, data []byte) (io.WriteCloser, error) {
+	if len(nonce) != aead.NonceSize()-streamCounterSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return &encryptingWriter{
+		aead: aead,
+		w:    w,
+
+		nonce: append([]byte(nil), nonce...),
+		more:  streamData(streamFrameMore, data),
+		final: streamData(streamFrameFinal, data),
+
+		buf: make([]byte, 0, streamChunkSize),
+		out: make([]byte, streamHeaderSize, streamHeaderSize+streamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (ew *encryptingWriter) Write(p []byte) (n int, err error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+
+	for len(p) > 0 {
+		if len(ew.buf) == cap(ew.buf) {
+			if ew.err = ew.flush(streamFrameMore); ew.err != nil {
+				return n, ew.err
+			}
+		}
+
+		m := copy(ew.buf[len(ew.buf):cap(ew.buf)], p)
+		ew.buf = ew.buf[:len(ew.buf)+m]
+
+		n += m
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+func (ew *encryptingWriter) Close() error {
+	if ew.err != nil {
+		return ew.err
+	}
+
+	if ew.err = ew.flush(streamFrameFinal); ew.err == nil {
+		ew.err = errStreamClosed
+		return nil
+	}
+
+	return ew.err
+}
+
+// flush seals the buffered plaintext as a single frame and writes it.
+func (ew *encryptingWriter) flush(flag byte) error {
+	if ew.counter > math.MaxUint32 {
+		return ErrNoncesExhausted
+	}
+
+	data := ew.more
+	if flag == streamFrameFinal {
+		data = ew.final
+	}
+
+	out := ew.aead.Seal(ew.out[:streamHeaderSize], streamNonce(ew.nonce, ew.counter), ew.buf, data)
+	out[0] = flag
+	binary.LittleEndian.PutUint32(out[1:streamHeaderSize], uint32(len(out)-streamHeaderSize))
+
+	wipe(ew.buf)
+	ew.buf = ew.buf[:0]
+	ew.counter++
+
+	_, err := ew.w.Write(out)
+	return err
+}
+
+type decryptingReader struct {
+	aead cipher.AEAD
+	r    io.Reader
+
+	nonce []byte
+	more  []byte
+	final []byte
+
+	counter uint64
+	frame   []byte
+	plain   []byte // decrypted, but not yet read, plaintext
+	done    bool   // the final frame has been opened
+
+	err error
+}
+
+// NewDecryptingReader returns an io.Reader that reads a stream written by
+// NewEncryptingWriter from r and decrypts it with aead. nonce and data must
+// match those passed to NewEncryptingWriter; nonce must be 4 bytes shorter
+// than aead.NonceSize(), otherwise ErrInvalidNonce is returned.
+//
+// Each frame is authenticated before any of its plaintext is returned. If a
+// frame fails to authenticate, is malformed, or r ends before the final frame,
+// ErrAuthFailed is returned. Once the final frame has been read, io.EOF is
+// returned without reading further from r.
+func NewDecryptingReader(aead cipher.AEAD, r io.Reader, nonce, data []byte) (io.Reader, error) {
+	if len(nonce) != aead.NonceSize()-streamCounterSize {
+		return nil, ErrInvalidNonce
+	}
+
+	return &decryptingReader{
+		aead: aead,
+		r:    r,
+
+		nonce: append([]byte(nil), nonce...),
+		more:  streamData(streamFrameMore, data),
+		final: streamData(streamFrameFinal, data),
+
+		frame: make([]byte, streamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (dr *decryptingReader) Read(p []byte) (n int, err error) {
+	for len(dr.plain) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+
+		if dr.done {
+			return 0, io.EOF
+		}
+
+		dr.err = dr.next()
+	}
+
+	n = copy(p, dr.plain)
+	dr.plain = dr.plain[n:]
+	return n, nil
+}
+
+// next reads and opens the next frame.
+func (dr *decryptingReader) next() error {
+	if dr.counter > math.MaxUint32 {
+		return ErrNoncesExhausted
+	}
+
+	var hdr [streamHeaderSize]byte
+	if _, err := io.ReadFull(dr.r, hdr[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrAuthFailed
+	} else if err != nil {
+		return err
+	}
+
+	var data []byte
+	switch hdr[0] {
+	case streamFrameMore:
+		data = dr.more
+	case streamFrameFinal:
+		data = dr.final
+	default:
+		return ErrAuthFailed
+	}
+
+	length := binary.LittleEndian.Uint32(hdr[1:])
+	if length > uint32(len(dr.frame)) {
+		return ErrAuthFailed
+	}
+
+	frame := dr.frame[:length]
+	if _, err := io.ReadFull(dr.r, frame); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrAuthFailed
+	} else if err != nil {
+		return err
+	}
+
+	plain, err := dr.aead.Open(frame[:0], streamNonce(dr.nonce, dr.counter), frame, data)
+	if err != nil {
+		return ErrAuthFailed
+	}
+
+	dr.plain = plain
+	dr.counter++
+	dr.done = hdr[0] == streamFrameFinal
+	return nil
+}
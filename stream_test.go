@@ -0,0 +1,254 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"io/ioutil"
+	"testing"
+)
+
+func TestEncryptingWriter(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize()-4)
+	data := []byte("whoah yeah")
+
+	plaintext := make([]byte, 2*streamChunkSize+100)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+
+	var buf bytes.Buffer
+
+	w, err := NewEncryptingWriter(c, &buf, nonce, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write in odd-sized pieces to exercise the buffering.
+	for p := plaintext; len(p) > 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+
+		if _, err := w.Write(p[:n]); err != nil {
+			t.Fatal(err)
+		}
+
+		p = p[n:]
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write([]byte{0}); err == nil {
+		t.Error("Expected write after close to fail")
+	}
+
+	var actual []byte
+	stream := buf.Bytes()
+
+	for counter, flags := 0, []byte{0, 0, 1}; counter < len(flags); counter++ {
+		flag, length := stream[0], binary.LittleEndian.Uint32(stream[1:5])
+		if flag != flags[counter] {
+			t.Fatalf("Expected flag %d for frame %d but was %d", flags[counter], counter, flag)
+		}
+
+		frame := stream[5 : 5+length]
+		stream = stream[5+length:]
+
+		chunk, err := c.Open(nil, streamNonce(nonce, uint64(counter)), frame, streamData(flag, data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		actual = append(actual, chunk...)
+	}
+
+	if len(stream) != 0 {
+		t.Errorf("Expected no data after final frame but was %d bytes", len(stream))
+	}
+
+	if !bytes.Equal(plaintext, actual) {
+		t.Error("Bad stream: plaintext did not round-trip")
+	}
+
+	if _, err := NewEncryptingWriter(c, &buf, make([]byte, c.NonceSize()), data); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestEncryptingWriterEmpty(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	w, err := NewEncryptingWriter(c, &buf, make([]byte, c.NonceSize()-4), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.Len() != 5+c.Overhead() {
+		t.Errorf("Expected a single empty final frame of %d bytes but was %d", 5+c.Overhead(), buf.Len())
+	}
+}
+
+// encryptStream returns the frames of plaintext encrypted as a stream.
+func encryptStream(t *testing.T, c cipher.AEAD, nonce, data, plaintext []byte) [][]byte {
+	var buf bytes.Buffer
+
+	w, err := NewEncryptingWriter(c, &buf, nonce, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var frames [][]byte
+	for stream := buf.Bytes(); len(stream) > 0; {
+		n := 5 + int(binary.LittleEndian.Uint32(stream[1:5]))
+		frames = append(frames, stream[:n])
+		stream = stream[n:]
+	}
+
+	return frames
+}
+
+func TestDecryptingReader(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	for _, test := range []struct {
+		name string
+		new  func(key []byte) (cipher.AEAD, error)
+	}{
+		{"RFC", NewRFC},
+		{"X", NewX},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := test.new(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nonce := make([]byte, c.NonceSize()-4)
+			data := []byte("whoah yeah")
+
+			for _, l := range []int{0, 1, streamChunkSize, 3*streamChunkSize + 7} {
+				plaintext := make([]byte, l)
+				for i := range plaintext {
+					plaintext[i] = byte(i)
+				}
+
+				frames := encryptStream(t, c, nonce, data, plaintext)
+
+				r, err := NewDecryptingReader(c, bytes.NewReader(bytes.Join(frames, nil)), nonce, data)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				actual, err := ioutil.ReadAll(r)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(plaintext, actual) {
+					t.Errorf("Bad stream of %d bytes: plaintext did not round-trip", l)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptingReaderTampered(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	c, err := NewRFC(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize()-4)
+	data := []byte("whoah yeah")
+	plaintext := make([]byte, 2*streamChunkSize+100)
+
+	for _, test := range []struct {
+		name   string
+		tamper func(frames [][]byte) [][]byte
+	}{
+		{"Truncated", func(frames [][]byte) [][]byte {
+			return frames[:len(frames)-1]
+		}},
+		{"TruncatedFrame", func(frames [][]byte) [][]byte {
+			last := frames[len(frames)-1]
+			frames[len(frames)-1] = last[:len(last)-1]
+			return frames
+		}},
+		{"Reordered", func(frames [][]byte) [][]byte {
+			frames[0], frames[1] = frames[1], frames[0]
+			return frames
+		}},
+		{"Duplicated", func(frames [][]byte) [][]byte {
+			return append([][]byte{frames[0]}, frames...)
+		}},
+		{"Corrupted", func(frames [][]byte) [][]byte {
+			frames[1][10] ^= 1
+			return frames
+		}},
+		{"FinalFlag", func(frames [][]byte) [][]byte {
+			frames[0][0] = 1
+			return frames[:1]
+		}},
+		{"BadFlag", func(frames [][]byte) [][]byte {
+			frames[0][0] = 2
+			return frames
+		}},
+		{"Oversized", func(frames [][]byte) [][]byte {
+			binary.LittleEndian.PutUint32(frames[0][1:5], streamChunkSize+uint32(c.Overhead())+1)
+			return frames
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			frames := test.tamper(encryptStream(t, c, nonce, data, plaintext))
+
+			r, err := NewDecryptingReader(c, bytes.NewReader(bytes.Join(frames, nil)), nonce, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ioutil.ReadAll(r); err != ErrAuthFailed {
+				t.Errorf("Expected message authentication failed error but was %v", err)
+			}
+		})
+	}
+
+	if _, err := NewDecryptingReader(c, bytes.NewReader(nil), make([]byte, c.NonceSize()), data); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
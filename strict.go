@@ -0,0 +1,67 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// StrictOpenAEAD is implemented by the AEADs returned from NewRFC, NewDraft
+// and NewX. It allows a message to be opened without allocating and without
+// writing to dst unless the message is authentic.
+type StrictOpenAEAD interface {
+	cipher.AEAD
+
+	// OpenStrict behaves like Open but never allocates the plaintext.
+	// ErrShortBuffer is returned if the plaintext doesn't fit in the
+	// spare capacity of dst. The tag is checked before anything is
+	// written to dst, so, unlike Open, dst is left untouched if
+	// authentication fails.
+	OpenStrict(dst, nonce, ciphertext, data []byte) ([]byte, error)
+}
+
+func (k *chacha20Key) OpenStrict(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	if cap(dst)-len(dst) < len(ciphertext) {
+		return nil, ErrShortBuffer
+	}
+
+	ret := dst[:len(dst)+len(ciphertext)]
+	out := ret[len(dst):]
+	if inexactOverlap(out, ciphertext) {
+		panic(errInvalidOverlap)
+	}
+
+	c := k.stream(nonce)
+
+	pk := k.keyBlock(c)
+	defer k.putKeyBlock(pk)
+
+	if !k.verifyTag(pk[:32], ciphertext, tag, data) {
+		return nil, ErrAuthFailed
+	}
+
+	c.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+func (k *xchacha20Key) OpenStrict(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce); err != nil {
+		return nil, err
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.OpenStrict(dst, rfcNonce, ciphertext, data)
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testOpenStrict(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sc := c.(StrictOpenAEAD)
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	buf := make([]byte, 3, 3+len(plaintext))
+	copy(buf, "abc")
+
+	actual, err := sc.OpenStrict(buf, nonce, ciphertext, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := append([]byte("abc"), plaintext...); !bytes.Equal(expected, actual) {
+		t.Errorf("Bad open: expected %x, was %x", expected, actual)
+	}
+
+	if &actual[0] != &buf[0] {
+		t.Error("OpenStrict did not use the capacity of dst")
+	}
+
+	short := make([]byte, 0, len(plaintext)-1)
+	if actual, err = sc.OpenStrict(short, nonce, ciphertext, data); err != ErrShortBuffer || actual != nil {
+		t.Errorf("Expected short buffer error but was %v (%x)", err, actual)
+	}
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[0] ^= 1
+
+	dst := bytes.Repeat([]byte{42}, len(plaintext))
+	if actual, err = sc.OpenStrict(dst[:0], nonce, tampered, data); err != ErrAuthFailed || actual != nil {
+		t.Errorf("Expected message authentication failed error but was %v (%x)", err, actual)
+	}
+
+	if expected := bytes.Repeat([]byte{42}, len(plaintext)); !bytes.Equal(expected, dst) {
+		t.Errorf("Expected dst to be untouched but was %x", dst)
+	}
+
+	if _, err = sc.OpenStrict(nil, nonce, ciphertext[:TagSize-1], data); err != ErrCiphertextTooShort {
+		t.Errorf("Expected ciphertext too short error but was %v", err)
+	}
+}
+
+func TestRFCOpenStrict(t *testing.T) {
+	testOpenStrict(t, NewRFC)
+}
+
+func TestDraftOpenStrict(t *testing.T) {
+	testOpenStrict(t, NewDraft)
+}
+
+func TestXOpenStrict(t *testing.T) {
+	testOpenStrict(t, NewX)
+}
@@ -0,0 +1,38 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import "golang.org/x/crypto/poly1305"
+
+// ComputeTag returns the tag that Seal appends to ciphertext for the given
+// key, nonce and additional data. The one-time Poly1305 key is derived from
+// the counter-0 block exactly as Seal does, and the MAC input is framed as
+// for the draft construction if draft is true and as for RFC7539 otherwise.
+// The nonce must be 8 bytes long for the draft construction and 12 bytes
+// long for RFC7539.
+//
+// This is intended for protocol experiments; ciphertext is authenticated but
+// never decrypted.
+func ComputeTag(key []byte, nonce, ciphertext, data []byte, draft bool) ([poly1305.TagSize]byte, error) {
+	var tag [poly1305.TagSize]byte
+
+	if len(key) != KeySize {
+		return tag, ErrInvalidKey
+	}
+
+	k := &chacha20Key{draft: draft}
+	copy(k.key[:], key)
+	defer wipe(k.key[:])
+
+	if len(nonce) != k.NonceSize() {
+		return tag, ErrInvalidNonce
+	}
+
+	pk := k.keyBlock(k.stream(nonce))
+	defer k.putKeyBlock(pk)
+
+	k.auth(pk[:32], tag[:], ciphertext, data)
+	return tag, nil
+}
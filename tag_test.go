@@ -0,0 +1,59 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"testing"
+)
+
+func testComputeTag(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error), draft bool) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, c.NonceSize())
+	nonce[0] = 7
+
+	for _, size := range []int{0, 1, 15, 16, 17, 64, 1000} {
+		plaintext := bytes.Repeat([]byte{0x42}, size)
+		data := bytes.Repeat([]byte{0x24}, size/3)
+
+		sealed := c.Seal(nil, nonce, plaintext, data)
+		ciphertext, expected := sealed[:size], sealed[size:]
+
+		tag, err := ComputeTag(key, nonce, ciphertext, data, draft)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(expected, tag[:]) {
+			t.Errorf("Bad tag for %d bytes: expected %x, was %x", size, expected, tag)
+		}
+	}
+
+	if _, err = ComputeTag(key[:KeySize-1], nonce, nil, nil, draft); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+
+	if _, err = ComputeTag(key, nonce[1:], nil, nil, draft); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
+
+func TestRFCComputeTag(t *testing.T) {
+	testComputeTag(t, NewRFC, false)
+}
+
+func TestDraftComputeTag(t *testing.T) {
+	testComputeTag(t, NewDraft, true)
+}
@@ -0,0 +1,108 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	timeNonceMaxTick    = 1<<48 - 1
+	timeNonceSuffixSize = RFCNonceSize - 8
+)
+
+// TimeNonceScheme generates RFC7539 nonces that sort in the order they were
+// generated. Each nonce is a 48-bit, big-endian millisecond Unix timestamp,
+// followed by a 16-bit, big-endian counter of nonces generated within that
+// millisecond, followed by a 32-bit random suffix chosen when the scheme is
+// created.
+//
+// If the clock goes backwards, or more than 65536 nonces are generated
+// within a single millisecond, the timestamp is advanced past the last one
+// used so that nonces remain unique and increasing.
+//
+// A TimeNonceScheme is safe for concurrent use.
+type TimeNonceScheme struct {
+	aead cipher.AEAD
+	now  func() time.Time
+
+	suffix [timeNonceSuffixSize]byte
+
+	mu      sync.Mutex
+	tick    uint64
+	counter uint16
+	used    bool
+}
+
+// NewTimeNonceScheme returns a TimeNonceScheme that seals with c. c must use
+// 12-byte RFC7539 nonces, otherwise ErrInvalidNonce is returned. If now is
+// nil, time.Now is used.
+func NewTimeNonceScheme(c cipher.AEAD, now func() time.Time) (*TimeNonceScheme, error) {
+	if c.NonceSize() != RFCNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if now == nil {
+		now = time.Now
+	}
+
+	s := &TimeNonceScheme{
+		aead: c,
+		now:  now,
+	}
+
+	if _, err := io.ReadFull(rand.Reader, s.suffix[:]); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Next returns the next nonce in the scheme. It returns ErrNoncesExhausted if
+// the timestamp no longer fits in 48 bits.
+func (s *TimeNonceScheme) Next() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tick := uint64(s.now().UnixNano() / int64(time.Millisecond))
+
+	switch {
+	case !s.used || tick > s.tick:
+		s.tick, s.counter = tick, 0
+	case s.counter < math.MaxUint16:
+		s.counter++
+	default:
+		s.tick, s.counter = s.tick+1, 0
+	}
+
+	if s.tick > timeNonceMaxTick {
+		return nil, ErrNoncesExhausted
+	}
+
+	s.used = true
+
+	nonce := make([]byte, RFCNonceSize)
+	binary.BigEndian.PutUint64(nonce, s.tick<<16|uint64(s.counter))
+	copy(nonce[8:], s.suffix[:])
+	return nonce, nil
+}
+
+// SealTimed seals plaintext with a nonce stamped with the current time and
+// returns the nonce and ciphertext. The nonce must be conveyed to the
+// recipient.
+func (s *TimeNonceScheme) SealTimed(plaintext, data []byte) (nonce, ciphertext []byte, err error) {
+	nonce, err = s.Next()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return nonce, s.aead.Seal(nil, nonce, plaintext, data), nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestTimeNonceSchemeMonotonic(t *testing.T) {
+	c, err := NewRFC(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1500000000, 0)
+	times := []time.Duration{0, time.Millisecond, 5 * time.Millisecond, 2 * time.Millisecond, time.Second}
+
+	i := 0
+	s, err := NewTimeNonceScheme(c, func() time.Time {
+		ts := now.Add(times[i])
+		i++
+		return ts
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last []byte
+	for range times {
+		nonce, ciphertext, err := s.SealTimed([]byte("yay for me"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Compare(last, nonce) >= 0 {
+			t.Errorf("Expected nonce %x to sort after %x", nonce, last)
+		}
+
+		if _, err := c.Open(nil, nonce, ciphertext, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		last = nonce
+	}
+
+	if tick := binary.BigEndian.Uint64(last) >> 16; tick != uint64(now.Add(time.Second).UnixNano()/1e6) {
+		t.Errorf("Expected timestamp of %d but was %d", now.Add(time.Second).UnixNano()/1e6, tick)
+	}
+}
+
+func TestTimeNonceSchemeSameTick(t *testing.T) {
+	c, err := NewRFC(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1500000000, 0)
+	s, err := NewTimeNonceScheme(c, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const n = 1<<16 + 10
+
+	seen := make(map[string]bool, n)
+	var last []byte
+	for i := 0; i < n; i++ {
+		nonce, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if seen[string(nonce)] {
+			t.Fatalf("Nonce %x was generated twice", nonce)
+		}
+
+		if bytes.Compare(last, nonce) >= 0 {
+			t.Fatalf("Expected nonce %x to sort after %x", nonce, last)
+		}
+
+		seen[string(nonce)] = true
+		last = nonce
+	}
+}
+
+func TestTimeNonceSchemeInvalidNonce(t *testing.T) {
+	c, err := NewDraft(make([]byte, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewTimeNonceScheme(c, nil); err != ErrInvalidNonce {
+		t.Errorf("Expected invalid nonce error but was %v", err)
+	}
+}
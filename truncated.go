@@ -0,0 +1,105 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// minTruncatedTagSize is the shortest tag accepted by NewRFCTruncatedTag.
+const minTruncatedTagSize = 8
+
+// NewRFCTruncatedTag creates a new AEAD instance using the given key. The key
+// must be exactly 256 bits long. The returned cipher is a NON-STANDARD
+// variant of the RFC7539 AEAD construct that appends only the first tagLen
+// bytes of the tag. tagLen must be between 8 and 16, otherwise
+// ErrInvalidTagSize is returned. A tagLen of 16 is identical to NewRFC.
+//
+// Truncating the tag reduces the security against forgery: an attacker may
+// succeed with probability of roughly 2^-(8*tagLen) for each attempt. It
+// should only be used where bandwidth is scarce and that is acceptable.
+func NewRFCTruncatedTag(key []byte, tagLen int) (cipher.AEAD, error) {
+	return NewWithOptions(key, WithTagLength(tagLen))
+}
+
+// truncatedTagKey doesn't embed chacha20Key so as not to inherit its
+// extension methods, which all assume a full-length tag.
+type truncatedTagKey struct {
+	k chacha20Key
+
+	tagLen int
+}
+
+func (k *truncatedTagKey) NonceSize() int {
+	return k.k.NonceSize()
+}
+
+func (k *truncatedTagKey) Overhead() int {
+	return k.tagLen
+}
+
+func (k *truncatedTagKey) Seal(dst, nonce, plaintext, data []byte) []byte {
+	if err := k.k.checkSeal(nonce, len(plaintext), uint64(len(data))); err != nil {
+		panic(err)
+	}
+
+	c := k.k.stream(nonce)
+
+	pk := k.k.keyBlock(c)
+	defer k.k.putKeyBlock(pk)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+k.tagLen)
+	if inexactOverlap(out, plaintext) {
+		panic(errInvalidOverlap)
+	}
+
+	ciphertext := out[:len(plaintext)]
+	c.XORKeyStream(ciphertext, plaintext)
+
+	var tag [poly1305.TagSize]byte
+	k.k.auth(pk[:32], tag[:], ciphertext, data)
+	copy(out[len(plaintext):], tag[:k.tagLen])
+	return ret
+}
+
+func (k *truncatedTagKey) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.k.checkOpen(nonce, len(ciphertext), k.tagLen, uint64(len(data))); err != nil {
+		return nil, err
+	}
+
+	tag := ciphertext[len(ciphertext)-k.tagLen:]
+	ciphertext = ciphertext[:len(ciphertext)-k.tagLen]
+
+	c := k.k.stream(nonce)
+
+	pk := k.k.keyBlock(c)
+	defer k.k.putKeyBlock(pk)
+
+	var expectedTag [poly1305.TagSize]byte
+	k.k.auth(pk[:32], expectedTag[:], ciphertext, data)
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if inexactOverlap(out, ciphertext) {
+		panic(errInvalidOverlap)
+	}
+
+	if subtle.ConstantTimeCompare(expectedTag[:k.tagLen], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+
+		return nil, ErrAuthFailed
+	}
+
+	c.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
+
+func (k *truncatedTagKey) Destroy() {
+	k.k.Destroy()
+}
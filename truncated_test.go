@@ -0,0 +1,65 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRFCTruncatedTag(t *testing.T) {
+	for _, vector := range rfcTestVectors {
+		for _, tagLen := range []int{8, 12, 15, 16} {
+			c, err := NewRFCTruncatedTag(vector.key, tagLen)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Overhead() != tagLen {
+				t.Errorf("Expected overhead of %d but was %d", tagLen, c.Overhead())
+			}
+
+			expected := vector.ciphertext[:len(vector.plaintext)+tagLen]
+
+			ciphertext := c.Seal(nil, vector.nonce, vector.plaintext, vector.data)
+			if !bytes.Equal(expected, ciphertext) {
+				t.Errorf("Bad seal: expected %x, was %x", expected, ciphertext)
+			}
+
+			plaintext, err := c.Open(nil, vector.nonce, ciphertext, vector.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(vector.plaintext, plaintext) {
+				t.Errorf("Bad open: expected %x, was %x", vector.plaintext, plaintext)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 1
+
+			if _, err = c.Open(nil, vector.nonce, ciphertext, vector.data); err != ErrAuthFailed {
+				t.Errorf("Expected message authentication failed error but was %v", err)
+			}
+
+			if _, err = c.Open(nil, vector.nonce, ciphertext[:tagLen-1], vector.data); err != ErrCiphertextTooShort {
+				t.Errorf("Expected ciphertext too short error but was %v", err)
+			}
+		}
+	}
+}
+
+func TestRFCTruncatedTagInvalid(t *testing.T) {
+	key := make([]byte, KeySize)
+
+	for _, tagLen := range []int{-1, 0, 7, 17, 32} {
+		if _, err := NewRFCTruncatedTag(key, tagLen); err != ErrInvalidTagSize {
+			t.Errorf("Expected invalid tag size error for %d but was %v", tagLen, err)
+		}
+	}
+
+	if _, err := NewRFCTruncatedTag(key[:KeySize-1], 12); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
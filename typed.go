@@ -0,0 +1,73 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package chacha20poly1305
+
+import "crypto/cipher"
+
+// RFCNonce is a nonce for the RFC7539 construction.
+type RFCNonce [RFCNonceSize]byte
+
+// Bytes returns the nonce as a slice.
+func (n RFCNonce) Bytes() []byte { return n[:] }
+
+// DraftNonce is a nonce for the draft-agl-tls-chacha20poly1305-03
+// construction.
+type DraftNonce [DraftNonceSize]byte
+
+// Bytes returns the nonce as a slice.
+func (n DraftNonce) Bytes() []byte { return n[:] }
+
+// Nonce is the set of typed nonces accepted by TypedAEAD.
+type Nonce interface {
+	RFCNonce | DraftNonce
+
+	Bytes() []byte
+}
+
+// TypedAEAD wraps an AEAD so that it only accepts nonces of type N. Using a
+// nonce meant for the other construction is then a compile-time error rather
+// than a runtime ErrInvalidNonce panic.
+type TypedAEAD[N Nonce] struct {
+	aead cipher.AEAD
+}
+
+// NewTypedRFC behaves like NewRFC but returns a TypedAEAD accepting RFCNonce.
+func NewTypedRFC(key []byte) (*TypedAEAD[RFCNonce], error) {
+	c, err := NewRFC(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedAEAD[RFCNonce]{c}, nil
+}
+
+// NewTypedDraft behaves like NewDraft but returns a TypedAEAD accepting
+// DraftNonce.
+func NewTypedDraft(key []byte) (*TypedAEAD[DraftNonce], error) {
+	c, err := NewDraft(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedAEAD[DraftNonce]{c}, nil
+}
+
+// AEAD returns the underlying, untyped, AEAD.
+func (t *TypedAEAD[N]) AEAD() cipher.AEAD {
+	return t.aead
+}
+
+// Seal behaves like cipher.AEAD's Seal.
+func (t *TypedAEAD[N]) Seal(dst []byte, nonce N, plaintext, data []byte) []byte {
+	return t.aead.Seal(dst, nonce.Bytes(), plaintext, data)
+}
+
+// Open behaves like cipher.AEAD's Open.
+func (t *TypedAEAD[N]) Open(dst []byte, nonce N, ciphertext, data []byte) ([]byte, error) {
+	return t.aead.Open(dst, nonce.Bytes(), ciphertext, data)
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTypedRFC(t *testing.T) {
+	vector := rfcTestVectors[0]
+
+	c, err := NewTypedRFC(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nonce RFCNonce
+	copy(nonce[:], vector.nonce)
+
+	if actual := c.Seal(nil, nonce, vector.plaintext, vector.data); !bytes.Equal(vector.ciphertext, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", vector.ciphertext, actual)
+	}
+
+	actual, err := c.Open(nil, nonce, vector.ciphertext, vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+	}
+}
+
+func TestTypedDraft(t *testing.T) {
+	vector := draftTestVectors[0]
+
+	c, err := NewTypedDraft(vector.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nonce DraftNonce
+	copy(nonce[:], vector.nonce)
+
+	if actual := c.Seal(nil, nonce, vector.plaintext, vector.data); !bytes.Equal(vector.ciphertext, actual) {
+		t.Errorf("Bad seal: expected %x, was %x", vector.ciphertext, actual)
+	}
+
+	actual, err := c.Open(nil, nonce, vector.ciphertext, vector.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(vector.plaintext, actual) {
+		t.Errorf("Bad open: expected %x, was %x", vector.plaintext, actual)
+	}
+
+	if _, err = NewTypedDraft(vector.key[:31]); err != ErrInvalidKey {
+		t.Errorf("Expected invalid key error but was %v", err)
+	}
+}
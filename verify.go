@@ -0,0 +1,52 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	"golang.org/x/crypto/poly1305"
+)
+
+// VerifyAEAD is implemented by the AEADs returned from NewRFC, NewDraft and
+// NewX. It allows a message to be authenticated without decrypting it.
+type VerifyAEAD interface {
+	cipher.AEAD
+
+	// Verify checks the tag of ciphertext, which must include the tag,
+	// against nonce and data. It returns nil exactly when Open would
+	// succeed and otherwise returns the error that Open would return,
+	// without allocating or writing a plaintext.
+	Verify(nonce, ciphertext, data []byte) error
+}
+
+func (k *chacha20Key) Verify(nonce, ciphertext, data []byte) error {
+	if err := k.checkOpen(nonce, len(ciphertext), poly1305.TagSize, uint64(len(data))); err != nil {
+		return err
+	}
+
+	pk := k.keyBlock(k.stream(nonce))
+	defer k.putKeyBlock(pk)
+
+	tag := ciphertext[len(ciphertext)-poly1305.TagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-poly1305.TagSize]
+
+	if !k.verifyTag(pk[:32], ciphertext, tag, data) {
+		return ErrAuthFailed
+	}
+
+	return nil
+}
+
+func (k *xchacha20Key) Verify(nonce, ciphertext, data []byte) error {
+	if err := k.checkOpen(nonce); err != nil {
+		return err
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.Verify(rfcNonce, ciphertext, data)
+}
@@ -0,0 +1,99 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"testing"
+)
+
+func testVerify(t *testing.T, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := c.(VerifyAEAD)
+
+	nonce := make([]byte, c.NonceSize())
+	plaintext := []byte("yay for me, yay for me, yay for me")
+	data := []byte("whoah yeah")
+	ciphertext := c.Seal(nil, nonce, plaintext, data)
+
+	check := func(name string, nonce, ciphertext, data []byte) {
+		_, openErr := c.Open(nil, nonce, ciphertext, data)
+
+		if err := v.Verify(nonce, ciphertext, data); err != openErr {
+			t.Errorf("%s: expected %v error from Verify but was %v", name, openErr, err)
+		}
+	}
+
+	check("valid", nonce, ciphertext, data)
+	check("no data", nonce, c.Seal(nil, nonce, plaintext, nil), nil)
+	check("empty", nonce, c.Seal(nil, nonce, nil, data), data)
+	check("short", nonce, ciphertext[:TagSize-1], data)
+	check("wrong data", nonce, ciphertext, data[1:])
+
+	for i := range ciphertext {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[i] ^= 0x80
+		check("tampered ciphertext", nonce, tampered, data)
+	}
+
+	badNonce := append([]byte(nil), nonce...)
+	badNonce[0] ^= 1
+	check("wrong nonce", badNonce, ciphertext, data)
+
+	if err := v.Verify(nonce, ciphertext, data); err != nil {
+		t.Errorf("Expected no error but was %v", err)
+	}
+
+	if err := v.Verify(badNonce, ciphertext, data); err != ErrAuthFailed {
+		t.Errorf("Expected message authentication failed error but was %v", err)
+	}
+}
+
+func TestRFCVerify(t *testing.T) {
+	testVerify(t, NewRFC)
+}
+
+func TestDraftVerify(t *testing.T) {
+	testVerify(t, NewDraft)
+}
+
+func TestXVerify(t *testing.T) {
+	testVerify(t, NewX)
+}
+
+func BenchmarkVerify(b *testing.B) {
+	key := make([]byte, KeySize)
+	c, _ := NewRFC(key)
+
+	nonce := make([]byte, c.NonceSize())
+	ciphertext := c.Seal(nil, nonce, make([]byte, 1024), nil)
+
+	b.Run("Open", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(ciphertext)))
+
+		for i := 0; i < b.N; i++ {
+			c.Open(nil, nonce, ciphertext, nil)
+		}
+	})
+
+	b.Run("Verify", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(ciphertext)))
+
+		for i := 0; i < b.N; i++ {
+			c.(VerifyAEAD).Verify(nonce, ciphertext, nil)
+		}
+	})
+}
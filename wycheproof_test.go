@@ -0,0 +1,162 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// wycheproofDir holds the chacha20_poly1305_test.json and
+// xchacha20_poly1305_test.json files, vendored unmodified from the
+// testvectors_v1 directory of https://github.com/C2SP/wycheproof along with
+// its LICENSE.
+var wycheproofDir = filepath.Join("testdata", "wycheproof")
+
+type wycheproofAEADTest struct {
+	TcID    int      `json:"tcId"`
+	Comment string   `json:"comment"`
+	Key     string   `json:"key"`
+	IV      string   `json:"iv"`
+	AAD     string   `json:"aad"`
+	Msg     string   `json:"msg"`
+	CT      string   `json:"ct"`
+	Tag     string   `json:"tag"`
+	Result  string   `json:"result"`
+	Flags   []string `json:"flags"`
+}
+
+type wycheproofAEADFile struct {
+	Algorithm  string `json:"algorithm"`
+	TestGroups []struct {
+		Tests []wycheproofAEADTest `json:"tests"`
+	} `json:"testGroups"`
+}
+
+func testWycheproof(t *testing.T, name string, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	b, err := ioutil.ReadFile(filepath.Join(wycheproofDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var file wycheproofAEADFile
+	if err := json.Unmarshal(b, &file); err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	for _, group := range file.TestGroups {
+		for _, test := range group.Tests {
+			testWycheproofVector(t, test, newChaCha20Poly1305)
+			n++
+		}
+	}
+
+	if n == 0 {
+		t.Errorf("No %s vectors found in %s", file.Algorithm, name)
+	}
+}
+
+func testWycheproofVector(t *testing.T, test wycheproofAEADTest, newChaCha20Poly1305 func(key []byte) (cipher.AEAD, error)) {
+	key := mustHexDecode(test.Key)
+	nonce := mustHexDecode(test.IV)
+	data := mustHexDecode(test.AAD)
+	msg := mustHexDecode(test.Msg)
+	ct := mustHexDecode(test.CT)
+	ciphertext := append(ct, mustHexDecode(test.Tag)...)
+
+	valid := test.Result == "valid"
+
+	c, err := newChaCha20Poly1305(key)
+	if err != nil {
+		if valid {
+			t.Errorf("tcId %d (%s): %v", test.TcID, test.Comment, err)
+		}
+
+		return
+	}
+
+	if len(nonce) != c.NonceSize() {
+		if valid {
+			t.Errorf("tcId %d (%s): valid vector with %d byte nonce", test.TcID, test.Comment, len(nonce))
+		}
+
+		return
+	}
+
+	dst := bytes.Repeat([]byte{0x42}, len(ct))
+
+	actual, err := c.Open(dst[:0], nonce, ciphertext, data)
+	if valid {
+		if err != nil {
+			t.Errorf("tcId %d (%s): %v", test.TcID, test.Comment, err)
+		} else if !bytes.Equal(msg, actual) {
+			t.Errorf("tcId %d (%s): bad open: expected %x, was %x", test.TcID, test.Comment, msg, actual)
+		}
+
+		if sealed := c.Seal(nil, nonce, msg, data); !bytes.Equal(ciphertext, sealed) {
+			t.Errorf("tcId %d (%s): bad seal: expected %x, was %x", test.TcID, test.Comment, ciphertext, sealed)
+		}
+
+		return
+	}
+
+	if err == nil || actual != nil {
+		t.Errorf("tcId %d (%s): expected invalid vector to fail but was %v (%x)", test.TcID, test.Comment, err, actual)
+	}
+
+	// dst must either have been zeroed or never written to.
+	untouched := bytes.Repeat([]byte{0x42}, len(dst))
+	if !bytes.Equal(dst, untouched) && !bytes.Equal(dst, make([]byte, len(dst))) {
+		t.Errorf("tcId %d (%s): invalid vector leaked %x", test.TcID, test.Comment, dst)
+	}
+}
+
+func TestWycheproofRFC(t *testing.T) {
+	testWycheproof(t, "chacha20_poly1305_test.json", NewRFC)
+}
+
+func TestWycheproofX(t *testing.T) {
+	testWycheproof(t, "xchacha20_poly1305_test.json", NewX)
+}
+
+func TestWycheproofVector(t *testing.T) {
+	// The harness itself is exercised with the RFC7539 section 2.8.2
+	// vector, in the Wycheproof format, and with corruptions of it.
+	vector := wycheproofAEADTest{
+		Key: "808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f",
+		IV:  "070000004041424344454647",
+		AAD: "50515253c0c1c2c3c4c5c6c7",
+		Msg: hex.EncodeToString([]byte("Ladies and Gentlemen of the class of '99: " +
+			"If I could offer you only one tip for the future, sunscreen would be it.")),
+		CT: "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6" +
+			"3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36" +
+			"92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc" +
+			"3ff4def08e4b7a9de576d26586cec64b6116",
+		Tag:    "1ae10b594f09e26a7e902ecbd0600691",
+		Result: "valid",
+	}
+
+	testWycheproofVector(t, vector, NewRFC)
+
+	for _, corrupt := range []func(v *wycheproofAEADTest){
+		func(v *wycheproofAEADTest) { v.Tag = "1ae10b594f09e26a7e902ecbd0600690" },
+		func(v *wycheproofAEADTest) { v.CT = "d21a" + v.CT[4:] },
+		func(v *wycheproofAEADTest) { v.AAD = "" },
+		func(v *wycheproofAEADTest) { v.IV = v.IV[:16] },
+		func(v *wycheproofAEADTest) { v.Key = v.Key[:62] },
+	} {
+		invalid := vector
+		invalid.Result = "invalid"
+		corrupt(&invalid)
+
+		testWycheproofVector(t, invalid, NewRFC)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+
+	xchacha20 "golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/poly1305"
+)
+
+// NewX creates a new AEAD instance using the given key. The key must be
+// exactly 256 bits long. The returned cipher is an implementation of the
+// XChaCha20-Poly1305 AEAD construct, as described in
+// draft-irtf-cfrg-xchacha, and is compatible with libsodium's
+// crypto_aead_xchacha20poly1305_ietf.
+//
+// XChaCha20-Poly1305 takes a 24-byte nonce, which is long enough that nonces
+// may be safely generated at random. The first 16 bytes of the nonce are used
+// with HChaCha20 to derive a subkey, which is then used with the RFC7539
+// construction and a nonce of four zero bytes followed by the remaining 8
+// bytes.
+func NewX(key []byte) (cipher.AEAD, error) {
+	return NewWithOptions(key, WithMode(ModeX))
+}
+
+type xchacha20Key struct {
+	// key is never written after construction, other than by Destroy
+	// and UnmarshalBinary, so that Seal and Open may be called
+	// concurrently from multiple goroutines.
+	key [KeySize]byte
+
+	destroyed bool // set by Destroy
+}
+
+func (k *xchacha20Key) NonceSize() int {
+	return XNonceSize
+}
+
+func (k *xchacha20Key) Overhead() int {
+	return poly1305.TagSize
+}
+
+// subkey returns the RFC7539 AEAD and nonce for the given extended nonce.
+func (k *xchacha20Key) subkey(nonce []byte) (*chacha20Key, []byte) {
+	if len(nonce) != XNonceSize {
+		panic(ErrInvalidNonce)
+	}
+
+	subkey, err := xchacha20.HChaCha20(k.key[:], nonce[:16])
+	if err != nil {
+		panic(err) // basically impossible
+	}
+
+	sk := new(chacha20Key)
+	copy(sk.key[:], subkey)
+	wipe(subkey)
+
+	rfcNonce := make([]byte, RFCNonceSize)
+	copy(rfcNonce[4:], nonce[16:])
+	return sk, rfcNonce
+}
+
+func (k *xchacha20Key) Seal(dst, nonce, plaintext, data []byte) []byte {
+	ret, err := k.sealSafe(1, dst, nonce, plaintext, data)
+	if err != nil {
+		panic(err)
+	}
+
+	return ret
+}
+
+func (k *xchacha20Key) SealSafe(dst, nonce, plaintext, data []byte) ([]byte, error) {
+	return k.sealSafe(1, dst, nonce, plaintext, data)
+}
+
+// sealSafe implements Seal and SealSafe. calldepth is passed on to checkDst.
+func (k *xchacha20Key) sealSafe(calldepth int, dst, nonce, plaintext, data []byte) ([]byte, error) {
+	if len(nonce) != XNonceSize {
+		return nil, ErrInvalidNonce
+	}
+
+	if k.destroyed {
+		return nil, ErrInvalidKey
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.sealSafe(calldepth+1, dst, rfcNonce, plaintext, data)
+}
+
+// checkOpen panics if nonce is the wrong size, as chacha20Key.checkOpen does,
+// and otherwise returns ErrInvalidKey if k has been destroyed. Every open
+// entry point calls it before deriving the subkey.
+func (k *xchacha20Key) checkOpen(nonce []byte) error {
+	if len(nonce) != XNonceSize {
+		panic(ErrInvalidNonce)
+	}
+
+	if k.destroyed {
+		return ErrInvalidKey
+	}
+
+	return nil
+}
+
+func (k *xchacha20Key) Open(dst, nonce, ciphertext, data []byte) ([]byte, error) {
+	if err := k.checkOpen(nonce); err != nil {
+		return nil, err
+	}
+
+	sk, rfcNonce := k.subkey(nonce)
+	defer wipe(sk.key[:])
+
+	return sk.Open(dst, rfcNonce, ciphertext, data)
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Coda Hale. All rights reserved.
+// Use of this source code is governed by an MIT
+// License that can be found in the LICENSE file.
+
+package chacha20poly1305
+
+import (
+	"bytes"
+	"testing"
+
+	xcrypto "golang.org/x/crypto/chacha20poly1305"
+)
+
+// xTestVectors are from draft-irtf-cfrg-xchacha-03, section A.3.1.
+var xTestVectors = []testVector{
+	{
+		key:       mustHexDecode("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f"),
+		nonce:     mustHexDecode("404142434445464748494a4b4c4d4e4f5051525354555657"),
+		plaintext: []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it."),
+		data:      mustHexDecode("50515253c0c1c2c3c4c5c6c7"),
+		ciphertext: mustHexDecode("bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb" +
+			"731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b452" +
+			"2f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9" +
+			"21f9664c97637da9768812f615c68b13b52e" +
+			"c0875924c1c7987947deafd8780acf49"),
+	},
+}
+
+func TestXSealing(t *testing.T) {
+	testSealing(t, NewX, xTestVectors)
+}
+
+func TestXOpening(t *testing.T) {
+	testOpening(t, NewX, xTestVectors)
+}
+
+func TestXRoundtrip(t *testing.T) {
+	testRoundtrip(t, NewX)
+}
+
+func TestXModifiedData(t *testing.T) {
+	testModified(t, NewX, true)
+}
+
+func TestXModifiedCiphertext(t *testing.T) {
+	testModified(t, NewX, false)
+}
+
+func TestXNonceSize(t *testing.T) {
+	testNonceSize(t, NewX, 24)
+}
+
+func TestXOverhead(t *testing.T) {
+	testOverhead(t, NewX)
+}
+
+func TestXInvalidKey(t *testing.T) {
+	testInvalidKey(t, NewX)
+}
+
+func TestXSealInvalidNonce(t *testing.T) {
+	testSealInvalidNonce(t, NewX)
+}
+
+func TestXOpenInvalidNonce(t *testing.T) {
+	testOpenInvalidNonce(t, NewX)
+}
+
+func TestXEqual(t *testing.T) {
+	key := make([]byte, KeySize)
+	nonce := make([]byte, 24)
+	data := []byte("whoah yeah")
+
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	for i := range nonce {
+		nonce[i] = byte(i * 3)
+	}
+
+	c, err := NewX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := xcrypto.NewX(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for l := 0; l < 300; l += 7 {
+		plaintext := bytes.Repeat([]byte{byte(l)}, l)
+
+		expected := x.Seal(nil, nonce, plaintext, data)
+		if actual := c.Seal(nil, nonce, plaintext, data); !bytes.Equal(expected, actual) {
+			t.Errorf("Bad seal of %d bytes: expected %x, was %x", l, expected, actual)
+		}
+
+		actual, err := c.Open(nil, nonce, expected, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(plaintext, actual) {
+			t.Errorf("Bad open of %d bytes: expected %x, was %x", l, plaintext, actual)
+		}
+	}
+}